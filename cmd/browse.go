@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse [query]",
+	Short: "Browse the bin full screen, restoring or purging items",
+	Long: `browse lists every tossed item full screen. Typing filters the list by
+fuzzy matching the original paths; a pane beside the list previews the item
+under the cursor, showing the start of a file or the tree of a directory.
+Tab selects several items. Enter restores them, ctrl-x purges them after
+asking, ctrl-o shows everything recorded about an item instead of its
+contents, and esc quits.
+
+When stdin or stdout is not a terminal, browse falls back to the numbered
+picker and restores the item chosen.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := ""
+		if len(args) == 1 {
+			query = args[0]
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		entries, err := db.All(database)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("bin is empty")
+			return nil
+		}
+
+		action, chosen := ui.ActionRestore, entries
+		if ui.IsTerminal(os.Stdin) && ui.IsTerminal(os.Stdout) {
+			action, chosen, err = ui.Browse(entries, query, b.Path)
+			if err != nil {
+				return err
+			}
+		} else {
+			if query != "" {
+				if chosen, err = db.FindByQuery(database, query); err != nil {
+					return err
+				}
+				if len(chosen) == 0 {
+					return fmt.Errorf("no matching items found for %q", query)
+				}
+			}
+			if len(chosen) > 1 {
+				e, err := ui.PickEntry(chosen)
+				if err != nil {
+					return err
+				}
+				chosen = []db.Entry{e}
+			}
+		}
+
+		failed := 0
+		for _, e := range chosen {
+			switch {
+			case dryRun && action == ui.ActionRestore:
+				planRestore(b, e, e.OriginalPath, "")
+				continue
+			case dryRun:
+				planDelete(b, e)
+				continue
+			}
+			switch action {
+			case ui.ActionRestore:
+				dest, err := restoreEntry(b, database, e, e.OriginalPath, "")
+				switch {
+				case err != nil:
+					fmt.Fprintf(os.Stderr, "toss: restoring %s: %v\n", e.OriginalPath, err)
+					failed++
+				case dest == "":
+					fmt.Printf("skipped: %s\n", e.OriginalPath)
+				case dest != e.OriginalPath:
+					fmt.Printf("restored: %s -> %s\n", e.OriginalPath, dest)
+				default:
+					fmt.Printf("restored: %s\n", e.OriginalPath)
+				}
+			case ui.ActionPurge:
+				if err := deleteEntry(b, database, e); err != nil {
+					fmt.Fprintf(os.Stderr, "toss: %v\n", err)
+					failed++
+					continue
+				}
+				fmt.Printf("purged: %s\n", e.OriginalPath)
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d item(s) failed", failed, len(chosen))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
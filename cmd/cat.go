@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:   "cat <id|query> [inner/path]",
+	Short: "Print a tossed file without restoring it",
+	Long: `cat writes the contents of a tossed file to standard output, or of the file
+at inner/path inside a tossed directory. The item is chosen like with info;
+when several match, a picker is shown unless --latest is given.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		latest, _ := cmd.Flags().GetBool("latest")
+		inner := ""
+		if len(args) == 2 {
+			inner = args[1]
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		entry, err := pickOne(database, args[0], latest)
+		if err != nil {
+			return err
+		}
+		path, err := bin.InnerPath(b, entry, inner)
+		if err != nil {
+			return err
+		}
+		// A symlink in the item may point anywhere, so it is not followed.
+		if info, err := os.Lstat(path); err != nil {
+			return err
+		} else if info.Mode()&fs.ModeSymlink != 0 {
+			rel, _ := filepath.Rel(b.Path(entry), path)
+			target, _ := os.Readlink(path)
+			return fmt.Errorf("%s is a symlink to %s", filepath.Join(entry.OriginalPath, rel), target)
+		} else if info.IsDir() {
+			return fmt.Errorf("%s is a directory; use 'toss ls' to see what is in it", entry.OriginalPath)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(os.Stdout, f)
+		return err
+	},
+}
+
+func init() {
+	catCmd.Flags().Bool("latest", false, "when several items match, use the most recently tossed")
+	rootCmd.AddCommand(catCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/diff"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <id|query> [path]",
+	Short: "Compare a tossed item with the file at its original path",
+	Long: `diff shows how the file now at a tossed item's original path, or at path if
+given, differs from the item: a unified diff for text files, sizes and
+SHA-256 hashes for binary files, and the files added, removed and changed
+for directories. The item is chosen like with info; when several match, a
+picker is shown unless --latest is given.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		latest, _ := cmd.Flags().GetBool("latest")
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		entry, err := pickOne(database, args[0], latest)
+		if err != nil {
+			return err
+		}
+
+		target := entry.OriginalPath
+		if len(args) == 2 {
+			if target, err = filepath.Abs(args[1]); err != nil {
+				return err
+			}
+		}
+		if _, err := os.Lstat(target); err != nil {
+			return fmt.Errorf("nothing to compare with: %w", err)
+		}
+		return showDiff(b.Path(entry), target, tossedName(entry, target))
+	},
+}
+
+// showDiff prints how the file at current differs from the tossed item at
+// tossed.
+func showDiff(tossed, current, tossedName string) error {
+	differ, err := diff.Compare(os.Stdout, tossed, current, tossedName, current)
+	if err != nil {
+		return err
+	}
+	if !differ {
+		fmt.Println("no differences")
+	}
+	return nil
+}
+
+// tossedName labels the tossed side of a comparison with path.
+func tossedName(e db.Entry, path string) string {
+	return fmt.Sprintf("%s (tossed %s)", path, e.TossedAt.Format("2006-01-02 15:04"))
+}
+
+func init() {
+	diffCmd.Flags().Bool("latest", false, "when several items match, compare the most recently tossed")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/ui"
+)
+
+// dryRun is set by the global --dry-run flag: commands print what they
+// would do to the bins and the index instead of doing it.
+var dryRun bool
+
+// planToss prints what tossing src would do and returns the entry it would
+// add.
+func planToss(b bin.Backend, src string) (db.Entry, error) {
+	e, err := b.Preview(src)
+	if err != nil {
+		return e, err
+	}
+	planMove("toss", e.OriginalPath, b.Path(e), e.SizeBytes, "")
+	fmt.Printf("  would add %s to the index\n", e.OriginalPath)
+	return e, nil
+}
+
+// planRestore prints what restoreEntry would do, without asking about a
+// collision.
+func planRestore(b bin.Backend, e db.Entry, dest, collision string) {
+	if planMove("restore", b.Path(e), dest, e.SizeBytes, collision) {
+		fmt.Printf("  would remove entry %s from the index\n", db.ShortID(e.ID))
+	}
+}
+
+// planDelete prints what deleteEntry would do.
+func planDelete(b bin.Backend, e db.Entry) {
+	fmt.Printf("would delete: %s (%s, %s)\n", e.OriginalPath, b.Path(e), ui.FormatSize(e.SizeBytes))
+	fmt.Printf("  would remove entry %s from the index\n", db.ShortID(e.ID))
+}
+
+// planMove prints what moving src to dest would do, settling a collision
+// at dest as makeRoom would but without asking, and reports whether src
+// would be moved at all.
+func planMove(verb, src, dest string, size int64, collision string) bool {
+	note := ""
+	if _, err := os.Lstat(dest); err == nil {
+		switch collision {
+		case "skip":
+			fmt.Printf("would skip: %s (%s exists)\n", src, dest)
+			return false
+		case "rename":
+			note = dest + " exists; would restore next to it"
+			renamed, err := bin.RestoredName(dest)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "toss: %s: %v\n", dest, err)
+				return false
+			}
+			dest = renamed
+		case "merge":
+			note = dest + " exists; would merge into it"
+		case "overwrite":
+			note = dest + " exists; would replace it"
+		default:
+			note = dest + " exists; would ask whether to replace it"
+		}
+	}
+	how := ui.FormatSize(size)
+	if bin.Crosses(src, dest) {
+		how += ", copied across filesystems"
+	}
+	fmt.Printf("would %s: %s -> %s (%s)\n", verb, src, dest, how)
+	if note != "" {
+		fmt.Printf("  %s\n", note)
+	}
+	return true
+}
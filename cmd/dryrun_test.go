@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Helpers
+
+// setHome points the bin and the config at a fresh home dir.
+func setHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	return home
+}
+
+// execute runs toss with args as a fresh process would, with every flag
+// back at its default, and returns the error of the command.
+func execute(t *testing.T, args ...string) error {
+	t.Helper()
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening %s: %v", os.DevNull, err)
+	}
+	defer devnull.Close()
+	return executeTo(t, devnull, args...)
+}
+
+// output is execute that returns what the command printed.
+func output(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	defer out.Close()
+	runErr := executeTo(t, out, args...)
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("reading stdout: %v", err)
+	}
+	return string(data), runErr
+}
+
+func executeTo(t *testing.T, w *os.File, args ...string) error {
+	t.Helper()
+	resetFlags(rootCmd)
+	dryRun = false
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+		if binLock != nil {
+			binLock.Close()
+			binLock = nil
+		}
+	}()
+
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// snapshot records every path under root with its mode and, for regular
+// files, a hash of the contents.
+func snapshot(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		state := info.Mode().String()
+		if info.Mode().IsRegular() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			state += fmt.Sprintf(" %x", sha256.Sum256(data))
+		}
+		files[path] = state
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking %s: %v", root, err)
+	}
+	return files
+}
+
+func checkUnchanged(t *testing.T, before, after map[string]string) {
+	t.Helper()
+	for path, state := range before {
+		if got, ok := after[path]; !ok {
+			t.Errorf("%s: removed", path)
+		} else if got != state {
+			t.Errorf("%s: changed from %q to %q", path, state, got)
+		}
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			t.Errorf("%s: created", path)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+// dbPath is where the index of the bin in the home dir set by setHome is.
+func dbPath(t *testing.T) string {
+	t.Helper()
+	_, path, err := bin.Paths()
+	if err != nil {
+		t.Fatalf("bin.Paths: %v", err)
+	}
+	return path
+}
+
+// entries reads the index the way a separate process would.
+func entries(t *testing.T) []db.Entry {
+	t.Helper()
+	d, err := db.Open(dbPath(t))
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+	all, err := db.All(d)
+	if err != nil {
+		t.Fatalf("db.All: %v", err)
+	}
+	return all
+}
+
+// Tests
+
+func TestDryRun_LeavesBinAndIndexAlone(t *testing.T) {
+	home := setHome(t)
+	work := filepath.Join(home, "work")
+	writeFile(t, filepath.Join(work, "a.txt"), "a")
+	writeFile(t, filepath.Join(work, "b.txt"), "b")
+	writeFile(t, filepath.Join(work, "dir", "c.txt"), "c")
+	if err := execute(t, filepath.Join(work, "a.txt"), filepath.Join(work, "dir")); err != nil {
+		t.Fatalf("toss: %v", err)
+	}
+	writeFile(t, filepath.Join(work, "a.txt"), "a again")
+	if err := execute(t, filepath.Join(work, "a.txt")); err != nil {
+		t.Fatalf("toss: %v", err)
+	}
+	all := entries(t)
+	if len(all) != 3 {
+		t.Fatalf("want 3 entries, got %d", len(all))
+	}
+	var id, dirID string
+	for _, e := range all {
+		if filepath.Base(e.OriginalPath) == "dir" {
+			dirID = e.ID
+		} else {
+			id = e.ID
+		}
+	}
+
+	for _, args := range [][]string{
+		{filepath.Join(work, "b.txt")},
+		{"restore", "--id", id},
+		{"restore", "--id", dirID, "--only", "c.txt"},
+		{"undo"},
+		{"undo", "--steps", "2"},
+		{"history", "--restore", "--version", "1", filepath.Join(work, "a.txt")},
+		{"purge", "--id", id},
+		{"purge", "--older-than", "0s"},
+		{"empty"},
+		{"gc", "--max-items", "1"},
+		{"fsck", "--repair"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			before := snapshot(t, home)
+			if err := execute(t, append([]string{"--dry-run"}, args...)...); err != nil {
+				t.Fatalf("toss --dry-run %s: %v", strings.Join(args, " "), err)
+			}
+			checkUnchanged(t, before, snapshot(t, home))
+		})
+	}
+}
+
+func TestDryRun_CreatesNothing(t *testing.T) {
+	home := setHome(t)
+	writeFile(t, filepath.Join(home, "a.txt"), "a")
+	before := snapshot(t, home)
+
+	if err := execute(t, "--dry-run", filepath.Join(home, "a.txt")); err != nil {
+		t.Fatalf("toss --dry-run: %v", err)
+	}
+	if err := execute(t, "--dry-run", "gc", "--max-items", "1"); err != nil {
+		t.Fatalf("toss --dry-run gc: %v", err)
+	}
+	checkUnchanged(t, before, snapshot(t, home))
+}
+
+func TestDryRun_RefusesMigration(t *testing.T) {
+	home := setHome(t)
+	writeFile(t, filepath.Join(home, "a.txt"), "a")
+	if err := execute(t, filepath.Join(home, "a.txt")); err != nil {
+		t.Fatalf("toss: %v", err)
+	}
+	d, err := sql.Open("sqlite", dbPath(t))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := d.Exec(`PRAGMA user_version = 1`); err != nil {
+		t.Fatalf("setting user_version: %v", err)
+	}
+	d.Close()
+	before := snapshot(t, home)
+
+	err = execute(t, "--dry-run", "empty")
+	if err == nil || !strings.Contains(err.Error(), "without --dry-run") {
+		t.Errorf("want the migration refused, got %v", err)
+	}
+	checkUnchanged(t, before, snapshot(t, home))
+}
+
+func TestDryRun_SaysItWouldAsk(t *testing.T) {
+	home := setHome(t)
+	writeFile(t, filepath.Join(home, ".toss", "config"), "confirm_args = 1\n")
+	writeFile(t, filepath.Join(home, "a.txt"), "a")
+	writeFile(t, filepath.Join(home, "b.txt"), "b")
+	before := snapshot(t, home)
+
+	out, err := output(t, "--dry-run", filepath.Join(home, "a.txt"), filepath.Join(home, "b.txt"))
+	if err != nil {
+		t.Fatalf("toss --dry-run: %v", err)
+	}
+	for _, want := range []string{"would ask before tossing 2 item(s)", "more than 1 items"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want %q in %q", want, out)
+		}
+	}
+	checkUnchanged(t, before, snapshot(t, home))
+}
+
+func TestDryRun_AutoGCCountsPlannedItems(t *testing.T) {
+	home := setHome(t)
+	writeFile(t, filepath.Join(home, ".toss", "config"), "auto_gc = true\nmax_items = 2\n")
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		writeFile(t, filepath.Join(home, name), name)
+	}
+	if err := execute(t, filepath.Join(home, "a.txt")); err != nil {
+		t.Fatalf("toss: %v", err)
+	}
+
+	out, err := output(t, "--dry-run", filepath.Join(home, "b.txt"), filepath.Join(home, "c.txt"))
+	if err != nil {
+		t.Fatalf("toss --dry-run: %v", err)
+	}
+	if want := "would delete: " + filepath.Join(home, "a.txt"); !strings.Contains(out, want) {
+		t.Errorf("want %q in %q", want, out)
+	}
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if strings.Contains(out, "would delete: "+filepath.Join(home, name)) {
+			t.Errorf("%s: planned for deletion though it would just be tossed", name)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/roman91DE/toss/internal/bin"
 	"github.com/roman91DE/toss/internal/db"
 	"github.com/roman91DE/toss/internal/ui"
 	"github.com/spf13/cobra"
@@ -16,12 +15,7 @@ var emptyCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		force, _ := cmd.Flags().GetBool("force")
 
-		binDir, dbPath, err := bin.Paths()
-		if err != nil {
-			return err
-		}
-
-		database, err := db.Open(dbPath)
+		b, database, err := openBin()
 		if err != nil {
 			return err
 		}
@@ -37,6 +31,23 @@ var emptyCmd = &cobra.Command{
 			return nil
 		}
 
+		if dryRun {
+			var total int64
+			for _, e := range entries {
+				planDelete(b, e)
+				total += e.SizeBytes
+			}
+			bins, err := b.Bins(database)
+			if err != nil {
+				return err
+			}
+			for _, binDir := range bins {
+				fmt.Printf("would empty: %s\n", binDir)
+			}
+			fmt.Printf("would permanently delete %d item(s), freeing %s\n", len(entries), ui.FormatSize(total))
+			return nil
+		}
+
 		if !force {
 			ok, err := ui.Confirm(fmt.Sprintf("Permanently delete %d item(s)?", len(entries)))
 			if err != nil {
@@ -48,11 +59,25 @@ var emptyCmd = &cobra.Command{
 			}
 		}
 
-		if err := bin.Empty(binDir); err != nil {
+		if err := lockBinExclusive(); err != nil {
+			return err
+		}
+		// Count again: others may have tossed or restored while we waited.
+		if entries, err = db.All(database); err != nil {
+			return err
+		}
+
+		bins, err := b.Bins(database)
+		if err != nil {
 			return err
 		}
+		for _, binDir := range bins {
+			if err := b.Empty(binDir); err != nil {
+				return err
+			}
+		}
 
-		if _, err := database.Exec(`DELETE FROM entries`); err != nil {
+		if err := db.Clear(database); err != nil {
 			return fmt.Errorf("clearing db: %w", err)
 		}
 
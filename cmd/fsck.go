@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the index against the bin and optionally repair it",
+	Long: `fsck reports entries whose item is gone from the bin, items in the bin with
+no entry, wrong recorded sizes and unreadable toss times. With --repair,
+orphaned items are adopted back into the index, entries without an item are
+dropped, and sizes and times are recomputed from the items themselves.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repair, _ := cmd.Flags().GetBool("repair")
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		if repair && !dryRun {
+			if err := lockBinExclusive(); err != nil {
+				return err
+			}
+		}
+
+		problems, err := bin.Check(b, database)
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Println("no problems found")
+			return nil
+		}
+		for _, p := range problems {
+			fmt.Println(describeProblem(p))
+		}
+
+		if !repair {
+			return fmt.Errorf("%d problem(s) found; run 'toss fsck --repair' to fix them", len(problems))
+		}
+		if dryRun {
+			fmt.Printf("would repair %d problem(s)\n", len(problems))
+			return nil
+		}
+		if err := bin.Repair(b, database, problems); err != nil {
+			return err
+		}
+		fmt.Printf("repaired %d problem(s)\n", len(problems))
+		return nil
+	},
+}
+
+func describeProblem(p bin.Problem) string {
+	switch p.Kind {
+	case bin.ProblemMissing:
+		return fmt.Sprintf("missing: %s (%s): %s is gone", p.Entry.OriginalPath, db.ShortID(p.Entry.ID), p.Path)
+	case bin.ProblemOrphan:
+		return fmt.Sprintf("orphan: %s has no entry", p.Path)
+	case bin.ProblemSize:
+		return fmt.Sprintf("size: %s (%s): recorded %s, actual %s", p.Entry.OriginalPath, db.ShortID(p.Entry.ID),
+			ui.FormatSize(p.Entry.SizeBytes), ui.FormatSize(p.Size))
+	case bin.ProblemBadTime:
+		return fmt.Sprintf("bad time: %s (%s): toss time cannot be read", p.Entry.OriginalPath, db.ShortID(p.Entry.ID))
+	default:
+		return p.Kind + ": " + p.Path
+	}
+}
+
+func init() {
+	fsckCmd.Flags().Bool("repair", false, "fix the problems found")
+	rootCmd.AddCommand(fsckCmd)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/gc"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Permanently delete the oldest items beyond the retention policy",
+	Long: `gc permanently deletes the oldest tossed items until the bin satisfies the
+retention policy set by max_age, max_size and max_items in ~/.toss/config.
+Flags override the configured limits for a single run.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		policy := cfg.Retention
+		if s, _ := cmd.Flags().GetString("max-age"); s != "" {
+			if policy.MaxAge, err = config.ParseAge(s); err != nil {
+				return err
+			}
+		}
+		if s, _ := cmd.Flags().GetString("max-size"); s != "" {
+			if policy.MaxSize, err = config.ParseSize(s); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("max-items") {
+			policy.MaxItems, _ = cmd.Flags().GetInt("max-items")
+		}
+		if policy.IsZero() {
+			return fmt.Errorf("no retention policy: set max_age, max_size or max_items in ~/.toss/config, or pass --max-age, --max-size or --max-items")
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		n, freed, err := collect(b, database, policy, dryRun, nil, nil)
+		if err != nil {
+			return err
+		}
+		switch {
+		case n == 0:
+			fmt.Println("nothing to collect")
+		case dryRun:
+			fmt.Printf("would delete %d item(s), freeing %s\n", n, ui.FormatSize(freed))
+		default:
+			fmt.Printf("deleted %d item(s), freed %s\n", n, ui.FormatSize(freed))
+		}
+		return nil
+	},
+}
+
+// collect permanently deletes the entries that fall outside r, oldest
+// first, except those whose IDs are in keep, and reports how many went and
+// how much space they held. planned entries, which a dry run would have
+// added, count as the newest in the index.
+func collect(b bin.Backend, d *sql.DB, r config.Retention, dryRun bool, keep map[string]bool, planned []db.Entry) (int, int64, error) {
+	entries, err := db.All(d)
+	if err != nil {
+		return 0, 0, err
+	}
+	entries = append(entries, planned...)
+
+	var n int
+	var freed int64
+	expired, over := gc.SelectKeeping(entries, r, time.Now(), keep)
+	if over {
+		fmt.Fprintf(os.Stderr, "toss: gc: the items just tossed exceed the retention policy on their own; keeping them\n")
+	}
+	for _, e := range expired {
+		if dryRun {
+			planDelete(b, e)
+		} else {
+			if err := deleteEntry(b, d, e); err != nil {
+				return n, freed, err
+			}
+			fmt.Printf("deleted: %s (%s)\n", e.OriginalPath, ui.FormatSize(e.SizeBytes))
+		}
+		n++
+		freed += e.SizeBytes
+	}
+	return n, freed, nil
+}
+
+// deleteEntry permanently removes an item from its bin and from the index.
+func deleteEntry(b bin.Backend, d *sql.DB, e db.Entry) error {
+	if err := bin.DeleteItem(b, d, e); err != nil {
+		return fmt.Errorf("deleting %s: %w", e.OriginalPath, err)
+	}
+	return nil
+}
+
+func init() {
+	gcCmd.Flags().String("max-age", "", "delete items tossed longer ago than this (e.g. 30d, 12h)")
+	gcCmd.Flags().String("max-size", "", "delete the oldest items until the bin is at most this size (e.g. 10GB)")
+	gcCmd.Flags().Int("max-items", 0, "delete the oldest items until at most this many remain")
+	rootCmd.AddCommand(gcCmd)
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/diff"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "List, restore and compare the tossed versions of a path",
+	Long: `history lists every item tossed from exactly this path as versions, oldest
+first and numbered from 1. --version N or --at TIME selects one: --at picks
+the version that was in place at that time, which is the first one tossed
+after it. A selected version is shown in detail, or restored with --restore.
+--diff N,M compares the contents of two versions, and --diff N compares a
+version with the file now at the path.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, _ := cmd.Flags().GetInt("version")
+		at, _ := cmd.Flags().GetString("at")
+		restore, _ := cmd.Flags().GetBool("restore")
+		diffSpec, _ := cmd.Flags().GetString("diff")
+		if version != 0 && at != "" {
+			return fmt.Errorf("--version and --at are mutually exclusive")
+		}
+		if restore && diffSpec != "" {
+			return fmt.Errorf("--restore and --diff are mutually exclusive")
+		}
+		if restore && version == 0 && at == "" {
+			return fmt.Errorf("--restore needs --version or --at")
+		}
+		collision, err := collisionFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		versions, err := db.FindByPath(database, path)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return fmt.Errorf("no tossed versions of %s", path)
+		}
+
+		if diffSpec != "" {
+			return diffVersions(b, versions, path, diffSpec)
+		}
+
+		var selected db.Entry
+		switch {
+		case version != 0:
+			if selected, err = pickVersion(versions, version); err != nil {
+				return err
+			}
+		case at != "":
+			t, err := config.ParseTime(at, time.Now())
+			if err != nil {
+				return err
+			}
+			if selected, err = versionAt(versions, t); err != nil {
+				return err
+			}
+		default:
+			ui.PrintHistory(versions)
+			return nil
+		}
+
+		if !restore {
+			ui.PrintInfo(selected, b.Path(selected))
+			return nil
+		}
+		if dryRun {
+			planRestore(b, selected, path, collision)
+			return nil
+		}
+		dest, err := restoreEntry(b, database, selected, path, collision)
+		switch {
+		case err != nil:
+			return fmt.Errorf("restoring %s: %w", path, err)
+		case dest == "":
+			fmt.Printf("skipped: %s\n", path)
+		case dest != path:
+			fmt.Printf("restored: %s -> %s\n", path, dest)
+		default:
+			fmt.Printf("restored: %s\n", path)
+		}
+		return nil
+	},
+}
+
+func pickVersion(versions []db.Entry, n int) (db.Entry, error) {
+	if n < 1 || n > len(versions) {
+		return db.Entry{}, fmt.Errorf("no version %d (there are %d)", n, len(versions))
+	}
+	return versions[n-1], nil
+}
+
+// versionAt returns the version that was at the path at time t: the first
+// one tossed after it.
+func versionAt(versions []db.Entry, t time.Time) (db.Entry, error) {
+	for _, e := range versions {
+		if e.TossedAt.After(t) {
+			return e, nil
+		}
+	}
+	return db.Entry{}, fmt.Errorf("no version was tossed after %s", t.Format("2006-01-02 15:04:05"))
+}
+
+// diffVersions compares the versions named by spec, "N,M" or "N" for
+// version N against the file now at path.
+func diffVersions(b bin.Backend, versions []db.Entry, path, spec string) error {
+	var nums []int
+	for _, part := range strings.Split(spec, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return fmt.Errorf("invalid --diff %q: want N or N,M", spec)
+		}
+		nums = append(nums, n)
+	}
+	if len(nums) > 2 {
+		return fmt.Errorf("invalid --diff %q: want N or N,M", spec)
+	}
+
+	a, err := pickVersion(versions, nums[0])
+	if err != nil {
+		return err
+	}
+	from, fromName := b.Path(a), fmt.Sprintf("%s@%d", path, nums[0])
+	to, toName := path, path
+	if len(nums) == 2 {
+		e, err := pickVersion(versions, nums[1])
+		if err != nil {
+			return err
+		}
+		to, toName = b.Path(e), fmt.Sprintf("%s@%d", path, nums[1])
+	}
+
+	differ, err := diff.Compare(os.Stdout, from, to, fromName, toName)
+	if err == nil && !differ {
+		fmt.Println("no differences")
+	}
+	return err
+}
+
+func init() {
+	historyCmd.Flags().Int("version", 0, "select version N (1 is the oldest)")
+	historyCmd.Flags().String("at", "", "select the version in place at this time (e.g. 2026-02-18, 7d)")
+	historyCmd.Flags().Bool("restore", false, "restore the selected version to the path")
+	historyCmd.Flags().String("diff", "", "compare versions N,M, or version N with the current file")
+	addCollisionFlags(historyCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info <id|query>",
+	Short: "Show everything recorded about tossed items",
+	Long: `info shows an item's original path, where it is in the bin, its size,
+mode, owner and modification time, and who tossed it, on which host, from
+which directory and with which command. The argument is an ID or unique ID
+prefix, or else a query matched against the filename or original path, in
+which case every match is shown.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		entries, err := findByIDOrQuery(database, args[0])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("no matching items found for %q", args[0])
+		}
+
+		if format != "text" {
+			return ui.WriteEntries(os.Stdout, entries, format)
+		}
+		for i, e := range entries {
+			if i > 0 {
+				fmt.Println()
+			}
+			ui.PrintInfo(e, b.Path(e))
+		}
+		return nil
+	},
+}
+
+// findByIDOrQuery treats arg as an ID prefix if it is long enough to be one
+// and some ID starts with it, and as a query otherwise.
+func findByIDOrQuery(d *sql.DB, arg string) ([]db.Entry, error) {
+	if len(arg) >= 4 {
+		matches, err := db.FindByIDPrefix(d, arg)
+		if err != nil || len(matches) > 0 {
+			return matches, err
+		}
+	}
+	return db.FindByQuery(d, arg)
+}
+
+// pickOne resolves arg like findByIDOrQuery to a single item, showing the
+// picker when several match unless latest picks the most recent.
+func pickOne(d *sql.DB, arg string, latest bool) (db.Entry, error) {
+	matches, err := findByIDOrQuery(d, arg)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	switch {
+	case len(matches) == 0:
+		return db.Entry{}, fmt.Errorf("no matching items found for %q", arg)
+	case len(matches) == 1:
+		return matches[0], nil
+	case latest:
+		return matches[len(matches)-1], nil
+	default:
+		return ui.PickEntry(matches)
+	}
+}
+
+func init() {
+	infoCmd.Flags().String("format", "text", "output format: text, "+strings.Join(ui.Formats, ", "))
+	rootCmd.AddCommand(infoCmd)
+}
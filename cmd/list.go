@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
 	"github.com/roman91DE/toss/internal/db"
 	"github.com/roman91DE/toss/internal/ui"
 	"github.com/spf13/cobra"
@@ -12,26 +16,48 @@ import (
 var listCmd = &cobra.Command{
 	Use:          "list",
 	Short:        "List all tossed items",
+	Args:         cobra.NoArgs,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		_, dbPath, err := bin.Paths()
+		format, _ := cmd.Flags().GetString("format")
+		tmpl, _ := cmd.Flags().GetString("template")
+		if tmpl != "" && format != "table" {
+			return fmt.Errorf("--template and --format cannot be combined")
+		}
+
+		filter, err := listFilter(cmd)
 		if err != nil {
 			return err
 		}
 
-		database, err := db.Open(dbPath)
+		_, database, err := openBin()
 		if err != nil {
 			return err
 		}
 		defer database.Close()
 
-		entries, err := db.All(database)
+		entries, err := db.List(database, filter)
 		if err != nil {
 			return err
 		}
 
+		switch {
+		case tmpl != "":
+			return ui.WriteTemplate(os.Stdout, entries, tmpl)
+		case format != "table":
+			return ui.WriteEntries(os.Stdout, entries, format)
+		}
+
 		if len(entries) == 0 {
-			fmt.Println("bin is empty")
+			empty, err := db.IsEmpty(database)
+			if err != nil {
+				return err
+			}
+			if empty {
+				fmt.Println("bin is empty")
+			} else {
+				fmt.Println("no matching items")
+			}
 			return nil
 		}
 
@@ -39,3 +65,61 @@ var listCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func listFilter(cmd *cobra.Command) (db.Filter, error) {
+	var f db.Filter
+	flags := cmd.Flags()
+	now := time.Now()
+
+	if s, _ := flags.GetString("since"); s != "" {
+		t, err := config.ParseTime(s, now)
+		if err != nil {
+			return f, err
+		}
+		f.Since = t
+	}
+	if s, _ := flags.GetString("until"); s != "" {
+		t, err := config.ParseTime(s, now)
+		if err != nil {
+			return f, err
+		}
+		f.Until = t
+	}
+	if s, _ := flags.GetString("path-prefix"); s != "" {
+		abs, err := filepath.Abs(s)
+		if err != nil {
+			return f, err
+		}
+		if strings.HasSuffix(s, "/") && abs != "/" {
+			abs += "/"
+		}
+		f.PathPrefix = abs
+	}
+	if s, _ := flags.GetString("min-size"); s != "" {
+		n, err := config.ParseSize(s)
+		if err != nil {
+			return f, err
+		}
+		f.MinSize = n
+	}
+	f.Glob, _ = flags.GetString("glob")
+	f.DirsOnly, _ = flags.GetBool("dirs-only")
+	f.Sort, _ = flags.GetString("sort")
+	f.Reverse, _ = flags.GetBool("reverse")
+	f.Limit, _ = flags.GetInt("limit")
+	return f, nil
+}
+
+func init() {
+	listCmd.Flags().String("format", "table", "output format: table, "+strings.Join(ui.Formats, ", "))
+	listCmd.Flags().String("template", "", "print each item with a Go text/template, e.g. '{{.ID}} {{.OriginalPath}}'")
+	listCmd.Flags().String("since", "", "only items tossed at or after this time (e.g. 2026-02-18, 7d)")
+	listCmd.Flags().String("until", "", "only items tossed at or before this time")
+	listCmd.Flags().String("path-prefix", "", "only items whose original path starts with this")
+	listCmd.Flags().String("glob", "", "only items whose original path matches this glob (e.g. '*.log')")
+	listCmd.Flags().String("min-size", "", "only items at least this large (e.g. 100MB)")
+	listCmd.Flags().Bool("dirs-only", false, "only directories")
+	listCmd.Flags().String("sort", "time", "sort by time, size or path")
+	listCmd.Flags().BoolP("reverse", "r", false, "reverse the sort order")
+	listCmd.Flags().IntP("limit", "n", 0, "show at most this many items")
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls <id|query> [inner/path]",
+	Short: "List what is inside a tossed directory",
+	Long: `ls lists everything inside a tossed directory, or inside inner/path within
+it, with paths relative to it and directories marked with a trailing /.
+The item is chosen like with info; when several match, a picker is shown
+unless --latest is given.`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		latest, _ := cmd.Flags().GetBool("latest")
+		long, _ := cmd.Flags().GetBool("long")
+		inner := ""
+		if len(args) == 2 {
+			inner = args[1]
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		entry, err := pickOne(database, args[0], latest)
+		if err != nil {
+			return err
+		}
+		root, err := bin.InnerPath(b, entry, inner)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(root, path)
+			if rel == "." {
+				if d.IsDir() {
+					return nil
+				}
+				rel = filepath.Base(entry.OriginalPath)
+				if inner != "" {
+					rel = filepath.Base(inner)
+				}
+			}
+			if d.IsDir() {
+				rel += "/"
+			}
+			if !long {
+				fmt.Fprintln(w, rel)
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Mode()&fs.ModeSymlink != 0 {
+				target, _ := os.Readlink(path)
+				rel += " -> " + target
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				info.Mode(), ui.FormatSize(info.Size()), info.ModTime().Format("2006-01-02 15:04"), rel)
+			return nil
+		})
+		w.Flush()
+		return err
+	},
+}
+
+func init() {
+	lsCmd.Flags().BoolP("long", "l", false, "show mode, size and modification time")
+	lsCmd.Flags().Bool("latest", false, "when several items match, use the most recently tossed")
+	rootCmd.AddCommand(lsCmd)
+}
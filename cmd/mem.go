@@ -5,7 +5,6 @@ import (
 	"io/fs"
 	"path/filepath"
 
-	"github.com/roman91DE/toss/internal/bin"
 	"github.com/roman91DE/toss/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -15,26 +14,34 @@ var memCmd = &cobra.Command{
 	Short:        "Show disk space used by the toss bin",
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		binDir, _, err := bin.Paths()
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		bins, err := b.Bins(database)
 		if err != nil {
 			return err
 		}
 
 		var total int64
-		err = filepath.WalkDir(binDir, func(_ string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return nil
-			}
-			if !d.IsDir() {
-				info, err := d.Info()
-				if err == nil {
-					total += info.Size()
+		for _, binDir := range bins {
+			err = filepath.WalkDir(binDir, func(_ string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if !d.IsDir() {
+					info, err := d.Info()
+					if err == nil {
+						total += info.Size()
+					}
 				}
+				return nil
+			})
+			if err != nil {
+				return err
 			}
-			return nil
-		})
-		if err != nil {
-			return err
 		}
 
 		fmt.Printf("bin usage: %s\n", ui.FormatSize(total))
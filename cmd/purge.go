@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge [query]",
+	Short: "Permanently delete specific tossed items",
+	Long: `purge permanently deletes items from the bin. Items are selected by a query
+(matched like 'toss restore'), by --id, or by the --older-than and
+--larger-than filters. A query without filters that matches several items
+shows a picker; with filters, every matching item is purged.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		id, _ := cmd.Flags().GetString("id")
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		largerThan, _ := cmd.Flags().GetString("larger-than")
+
+		var maxAge time.Duration
+		var minSize int64
+		var err error
+		if olderThan != "" {
+			if maxAge, err = config.ParseAge(olderThan); err != nil {
+				return err
+			}
+		}
+		if largerThan != "" {
+			if minSize, err = config.ParseSize(largerThan); err != nil {
+				return err
+			}
+		}
+		filtered := olderThan != "" || largerThan != ""
+		if len(args) == 0 && id == "" && !filtered {
+			return fmt.Errorf("specify a query, --id, --older-than or --larger-than (use 'toss empty' to delete everything)")
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		var entries []db.Entry
+		switch {
+		case id != "":
+			var e db.Entry
+			if e, err = findByID(database, id); err == nil {
+				entries = []db.Entry{e}
+			}
+		case len(args) == 1:
+			entries, err = db.FindByQuery(database, args[0])
+		default:
+			entries, err = db.All(database)
+		}
+		if err != nil {
+			return err
+		}
+
+		if filtered {
+			now := time.Now()
+			var kept []db.Entry
+			for _, e := range entries {
+				if olderThan != "" && now.Sub(e.TossedAt) <= maxAge {
+					continue
+				}
+				if largerThan != "" && e.SizeBytes <= minSize {
+					continue
+				}
+				kept = append(kept, e)
+			}
+			entries = kept
+		}
+
+		if len(entries) == 0 {
+			return fmt.Errorf("no matching items found")
+		}
+
+		if len(entries) > 1 && !filtered {
+			entry, err := ui.PickEntry(entries)
+			if err != nil {
+				return err
+			}
+			entries = []db.Entry{entry}
+		}
+
+		var total int64
+		for _, e := range entries {
+			total += e.SizeBytes
+		}
+
+		if dryRun {
+			for _, e := range entries {
+				planDelete(b, e)
+			}
+			fmt.Printf("would permanently delete %d item(s), freeing %s\n", len(entries), ui.FormatSize(total))
+			return nil
+		}
+
+		if !force {
+			ui.PrintTable(entries)
+			ok, err := ui.Confirm(fmt.Sprintf("Permanently delete %d item(s) (%s)?", len(entries), ui.FormatSize(total)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("aborted")
+				return nil
+			}
+		}
+
+		for _, e := range entries {
+			if err := deleteEntry(b, database, e); err != nil {
+				return err
+			}
+			fmt.Printf("purged: %s\n", e.OriginalPath)
+		}
+		return nil
+	},
+}
+
+func init() {
+	purgeCmd.Flags().BoolP("force", "f", false, "skip confirmation prompt")
+	purgeCmd.Flags().String("id", "", "purge the item with this ID or unique ID prefix")
+	purgeCmd.Flags().String("older-than", "", "only items tossed longer ago than this (e.g. 30d, 12h)")
+	purgeCmd.Flags().String("larger-than", "", "only items larger than this (e.g. 100MB)")
+	rootCmd.AddCommand(purgeCmd)
+}
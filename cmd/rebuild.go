@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/lock"
+	"github.com/spf13/cobra"
+)
+
+var rebuildCmd = &cobra.Command{
+	Use:   "rebuild-db",
+	Short: "Regenerate the database from the items in the bin",
+	Long: `rebuild-db recreates the index from what is in the bin: each item's sidecar
+record (or .trashinfo file with the freedesktop backend) restores its original
+path and toss time. Items without one are indexed under the top of their
+filesystem. An unreadable database is moved aside first.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dryRun {
+			return fmt.Errorf("rebuild-db cannot be previewed; 'toss fsck' shows what differs between the index and the bin")
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		b, err := bin.New(cfg)
+		if err != nil {
+			return err
+		}
+
+		path := b.DBPath()
+		database, err := db.Open(path)
+		if err == nil {
+			if err = db.Verify(database); err != nil {
+				database.Close()
+			}
+		}
+		if err != nil {
+			aside := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102-150405"))
+			if rerr := os.Rename(path, aside); rerr != nil {
+				return fmt.Errorf("moving aside unreadable db: %w", rerr)
+			}
+			// Its write-ahead log must not be replayed into the new database.
+			for _, suffix := range []string{"-wal", "-shm"} {
+				if rerr := os.Rename(path+suffix, aside+suffix); rerr != nil && !os.IsNotExist(rerr) {
+					return fmt.Errorf("moving aside unreadable db: %w", rerr)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "toss: %s is unreadable (%v); moved it to %s\n", path, err, aside)
+			if database, err = db.Open(path); err != nil {
+				return err
+			}
+		}
+		defer database.Close()
+
+		l, err := lock.Open(path + ".lock")
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+		if err := l.Exclusive(); err != nil {
+			return fmt.Errorf("locking bin: %w", err)
+		}
+		notes, err := bin.Recover(b, database)
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "toss: recovered: %s\n", note)
+		}
+		if err != nil {
+			return err
+		}
+
+		n, err := bin.Rebuild(b, database)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rebuilt database: %d item(s)\n", n)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rebuildCmd)
+}
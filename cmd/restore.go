@@ -1,81 +1,353 @@
 package cmd
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/roman91DE/toss/internal/bin"
 	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/search"
 	"github.com/roman91DE/toss/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var restoreCmd = &cobra.Command{
-	Use:          "restore [query]",
-	Short:        "Restore a tossed item to its original location",
-	Args:         cobra.MaximumNArgs(1),
+	Use:   "restore [query...]",
+	Short: "Restore tossed items to their original location",
+	Long: `restore moves tossed items back to where they came from. Each query is
+matched against the filename or original path, as a substring unless
+--fuzzy, --glob or --regex is given, and may use the qualifiers of
+'toss search' (dir:, name:, before:, after:, size>, size<) unless
+--literal is given; --id selects items by ID or unique ID prefix. When a query matches several items, an
+interactive picker is shown unless --all, --first or --latest decides.`,
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		binDir, dbPath, err := bin.Paths()
+		ids, _ := cmd.Flags().GetStringSlice("id")
+		all, _ := cmd.Flags().GetBool("all")
+		first, _ := cmd.Flags().GetBool("first")
+		latest, _ := cmd.Flags().GetBool("latest")
+		literal, _ := cmd.Flags().GetBool("literal")
+		if btoi(all)+btoi(first)+btoi(latest) > 1 {
+			return fmt.Errorf("--all, --first and --latest are mutually exclusive")
+		}
+
+		collision, err := collisionFlag(cmd)
 		if err != nil {
 			return err
 		}
-
-		database, err := db.Open(dbPath)
+		mode, err := searchMode(cmd, search.Substring)
 		if err != nil {
 			return err
 		}
-		defer database.Close()
+		only, _ := cmd.Flags().GetString("only")
+		if only != "" && collision == "merge" {
+			return fmt.Errorf("--merge cannot be used with --only")
+		}
 
-		var entries []db.Entry
-		if len(args) == 0 {
-			entries, err = db.All(database)
-		} else {
-			entries, err = db.FindByQuery(database, args[0])
+		to, _ := cmd.Flags().GetString("to")
+		if to != "" {
+			abs, err := filepath.Abs(to)
+			if err != nil {
+				return err
+			}
+			if strings.HasSuffix(to, "/") {
+				abs += "/"
+			}
+			to = abs
 		}
+
+		b, database, err := openBin()
 		if err != nil {
 			return err
 		}
+		defer database.Close()
+
+		var targets []db.Entry
+		seen := make(map[string]bool)
+		add := func(entries ...db.Entry) {
+			for _, e := range entries {
+				if !seen[e.ID] {
+					seen[e.ID] = true
+					targets = append(targets, e)
+				}
+			}
+		}
 
-		if len(entries) == 0 {
-			return fmt.Errorf("no matching items found")
+		failed := 0
+		for _, id := range ids {
+			e, err := findByID(database, id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "toss: %v\n", err)
+				failed++
+				continue
+			}
+			add(e)
 		}
 
-		var entry db.Entry
-		switch len(entries) {
-		case 1:
-			entry = entries[0]
-		default:
-			entry, err = ui.PickEntry(entries)
+		queries := args
+		if len(queries) == 0 && len(ids) == 0 {
+			queries = []string{""}
+		}
+		for _, q := range queries {
+			parsed, err := restoreQuery(q, literal)
 			if err != nil {
 				return err
 			}
+			matches, err := search.Find(database, parsed, mode)
+			if err != nil {
+				return err
+			}
+			if mode == search.Fuzzy && (first || latest) {
+				// --first and --latest go by toss time, not by rank.
+				slices.SortStableFunc(matches, func(a, b db.Entry) int {
+					return a.TossedAt.Compare(b.TossedAt)
+				})
+			}
+
+			switch {
+			case len(matches) == 0:
+				if q == "" {
+					fmt.Fprintf(os.Stderr, "toss: no matching items found\n")
+				} else {
+					fmt.Fprintf(os.Stderr, "toss: no matching items found for %q\n", q)
+				}
+				failed++
+			case len(matches) == 1 || all:
+				add(matches...)
+			case first:
+				add(matches[0])
+			case latest:
+				add(matches[len(matches)-1])
+			default:
+				entry, err := ui.PickEntry(matches)
+				if err != nil {
+					return err
+				}
+				add(entry)
+			}
+		}
+
+		if len(targets) > 1 && to != "" && !isDirTarget(to) {
+			return fmt.Errorf("--to must be a directory when restoring several items")
 		}
 
-		// Check if destination already exists
-		if _, err := os.Lstat(entry.OriginalPath); err == nil {
-			ok, err := ui.Confirm(fmt.Sprintf("%s already exists. Overwrite?", entry.OriginalPath))
+		if only != "" {
+			if len(targets) != 1 {
+				return fmt.Errorf("--only needs exactly one item, got %d", len(targets))
+			}
+			entry := targets[0]
+			src, err := bin.InnerPath(b, entry, only)
 			if err != nil {
 				return err
 			}
-			if !ok {
-				fmt.Println("aborted")
+			rel, _ := filepath.Rel(b.Path(entry), src)
+			orig := filepath.Join(entry.OriginalPath, rel)
+			if dryRun {
+				planMove("copy", src, restoreDest(orig, to), bin.Measure([]string{src}, true).Size, collision)
+				fmt.Println("  the item would stay in the bin")
 				return nil
 			}
-			if err := os.RemoveAll(entry.OriginalPath); err != nil {
-				return fmt.Errorf("removing existing file: %w", err)
+			dest, err := extractEntry(entry, src, restoreDest(orig, to), collision)
+			switch {
+			case err != nil:
+				return fmt.Errorf("restoring %s: %w", orig, err)
+			case dest == "":
+				fmt.Printf("skipped: %s\n", orig)
+			case dest != orig:
+				fmt.Printf("restored: %s -> %s\n", orig, dest)
+			default:
+				fmt.Printf("restored: %s\n", orig)
 			}
+			return nil
 		}
 
-		if err := bin.Restore(entry, binDir); err != nil {
-			return err
+		restored := 0
+		for _, entry := range targets {
+			if dryRun {
+				planRestore(b, entry, restoreDest(entry.OriginalPath, to), collision)
+				continue
+			}
+			dest, err := restoreEntry(b, database, entry, restoreDest(entry.OriginalPath, to), collision)
+			switch {
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "toss: restoring %s: %v\n", entry.OriginalPath, err)
+				failed++
+			case dest == "":
+				fmt.Printf("skipped: %s\n", entry.OriginalPath)
+			case dest != entry.OriginalPath:
+				fmt.Printf("restored: %s -> %s\n", entry.OriginalPath, dest)
+				restored++
+			default:
+				fmt.Printf("restored: %s\n", entry.OriginalPath)
+				restored++
+			}
 		}
 
-		if err := db.Remove(database, entry.ID); err != nil {
-			return fmt.Errorf("updating db: %w", err)
+		if failed > 0 {
+			return fmt.Errorf("%d item(s) restored, %d failed", restored, failed)
 		}
-
-		fmt.Printf("restored: %s\n", entry.OriginalPath)
 		return nil
 	},
 }
+
+// collisionFlag returns which of the flags added by addCollisionFlags was
+// given, or "" for none.
+func collisionFlag(cmd *cobra.Command) (string, error) {
+	collision := ""
+	for _, strategy := range []string{"rename", "skip", "overwrite", "merge"} {
+		if set, _ := cmd.Flags().GetBool(strategy); set {
+			if collision != "" {
+				return "", fmt.Errorf("--%s and --%s are mutually exclusive", collision, strategy)
+			}
+			collision = strategy
+		}
+	}
+	return collision, nil
+}
+
+func addCollisionFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("rename", false, "if the destination exists, restore as <name>.restored-N next to it")
+	cmd.Flags().Bool("skip", false, "if the destination exists, leave the item in the bin")
+	cmd.Flags().Bool("overwrite", false, "if the destination exists, replace it without asking")
+	cmd.Flags().Bool("merge", false, "if a directory is restored onto an existing directory, merge them")
+}
+
+// restoreEntry moves one item back to dest, first making room there as
+// makeRoom decides. It returns where the item ended up, or "" if it was
+// skipped.
+func restoreEntry(b bin.Backend, d *sql.DB, entry db.Entry, dest, collision string) (string, error) {
+	dest, merge, err := makeRoom(b.Path(entry), entry, dest, collision)
+	if err != nil || dest == "" {
+		return "", err
+	}
+	if err := bin.RestoreItem(b, d, entry, dest, merge); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// extractEntry copies src, inside the tossed directory entry, out to dest
+// and leaves the item in the bin. Collisions are handled as for
+// restoreEntry.
+func extractEntry(entry db.Entry, src, dest, collision string) (string, error) {
+	dest, _, err := makeRoom(src, entry, dest, collision)
+	if err != nil || dest == "" {
+		return "", err
+	}
+	if err := bin.Extract(src, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// makeRoom decides what happens when restoring src, part or all of entry,
+// to dest finds something already there: "rename" picks a new name next to
+// it, "skip" leaves both alone, "overwrite" removes it, "merge" merges
+// directories, and "" asks, offering a diff first. It returns where to
+// restore to, or "" to skip, and whether to merge into it.
+func makeRoom(src string, entry db.Entry, dest, collision string) (string, bool, error) {
+	if _, err := os.Lstat(dest); err != nil {
+		return dest, false, nil
+	}
+	switch collision {
+	case "skip":
+		return "", false, nil
+	case "rename":
+		renamed, err := bin.RestoredName(dest)
+		return renamed, false, err
+	case "merge":
+		return dest, true, nil
+	case "":
+		ok, err := ui.ConfirmOrDiff(fmt.Sprintf("%s already exists. Overwrite?", dest), func() error {
+			return showDiff(src, dest, tossedName(entry, dest))
+		})
+		if err != nil || !ok {
+			return "", false, err
+		}
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return "", false, fmt.Errorf("removing existing file: %w", err)
+	}
+	return dest, false, nil
+}
+
+// restoreDest maps an item's original path to its destination under --to.
+// An existing directory, or a path ending in "/", receives the item under
+// its original name; anything else is the new path itself.
+func restoreDest(original, to string) string {
+	switch {
+	case to == "":
+		return original
+	case isDirTarget(to):
+		return filepath.Join(to, filepath.Base(original))
+	default:
+		return to
+	}
+}
+
+func isDirTarget(to string) bool {
+	if strings.HasSuffix(to, "/") {
+		return true
+	}
+	info, err := os.Stat(to)
+	return err == nil && info.IsDir()
+}
+
+// findByID resolves a full ID or a unique prefix of one.
+func findByID(d *sql.DB, id string) (db.Entry, error) {
+	if len(id) < 4 {
+		return db.Entry{}, fmt.Errorf("ID prefix %q is too short (need at least 4 characters)", id)
+	}
+	matches, err := db.FindByIDPrefix(d, id)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	switch len(matches) {
+	case 0:
+		return db.Entry{}, fmt.Errorf("no item with ID %q", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return db.Entry{}, fmt.Errorf("ID prefix %q is ambiguous (%d items)", id, len(matches))
+	}
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// restoreQuery parses q for its qualifiers. A query without any, or any
+// query with literal set, is matched as typed, runs of spaces and all.
+func restoreQuery(q string, literal bool) (search.Query, error) {
+	if literal {
+		return search.Query{Text: q}, nil
+	}
+	parsed, err := search.Parse(q, time.Now())
+	if err != nil {
+		return parsed, err
+	}
+	if parsed == (search.Query{Text: parsed.Text}) {
+		parsed.Text = q
+	}
+	return parsed, nil
+}
+
+func init() {
+	restoreCmd.Flags().StringSlice("id", nil, "restore the item with this ID or unique ID prefix (repeatable)")
+	restoreCmd.Flags().Bool("all", false, "restore every item matching each query")
+	restoreCmd.Flags().Bool("first", false, "when a query matches several items, restore the oldest")
+	restoreCmd.Flags().Bool("latest", false, "when a query matches several items, restore the most recently tossed")
+	restoreCmd.Flags().Bool("literal", false, "match each query as typed, without reading qualifiers such as name: from it")
+	restoreCmd.Flags().String("to", "", "restore into this directory, or to this path, instead of the original location")
+	restoreCmd.Flags().String("only", "", "copy just this file or directory out of a tossed directory, leaving the item in the bin")
+	addCollisionFlags(restoreCmd)
+	addSearchFlags(restoreCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRestore_QueryAsTyped(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		args     []string
+		restored string
+	}{
+		{"double space", []string{"my notes.txt", "my  notes.txt"},
+			[]string{"my  notes"}, "my  notes.txt"},
+		{"name: in the name", []string{"draft.txt", "name:draft.txt"},
+			[]string{"--literal", "name:draft"}, "name:draft.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := setHome(t)
+			work := filepath.Join(home, "work")
+			for _, name := range tt.files {
+				path := filepath.Join(work, name)
+				writeFile(t, path, name)
+				if err := execute(t, path); err != nil {
+					t.Fatalf("toss %s: %v", name, err)
+				}
+			}
+
+			if err := execute(t, append([]string{"restore"}, tt.args...)...); err != nil {
+				t.Fatalf("restore: %v", err)
+			}
+			for _, name := range tt.files {
+				if want := name == tt.restored; exists(filepath.Join(work, name)) != want {
+					t.Errorf("%s: restored %v, want %v", name, !want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreQuery(t *testing.T) {
+	q, err := restoreQuery("a  b", false)
+	if err != nil || q.Text != "a  b" {
+		t.Errorf("restoreQuery(%q): got %q, %v", "a  b", q.Text, err)
+	}
+	q, err = restoreQuery("a  b name:c", false)
+	if err != nil || q.Text != "a b" || q.Name != "c" {
+		t.Errorf("restoreQuery(%q): got %+v, %v", "a  b name:c", q, err)
+	}
+	q, err = restoreQuery("name:c", true)
+	if err != nil || q.Text != "name:c" || q.Name != "" {
+		t.Errorf("restoreQuery(%q, literal): got %+v, %v", "name:c", q, err)
+	}
+}
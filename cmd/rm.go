@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/rmcompat"
+	"github.com/roman91DE/toss/internal/ui"
+	"golang.org/x/sys/unix"
+)
+
+const rmHelp = `Usage: %[1]s [OPTION]... [FILE]...
+Move each FILE to the toss bin, accepting the options of rm.
+
+  -f, --force           ignore nonexistent files and arguments, never prompt
+  -i                    prompt before every removal
+  -I                    prompt once before removing more than three files, or
+                          when removing recursively
+      --interactive[=WHEN]  prompt according to WHEN: never, once (-I), or
+                          always (-i); without WHEN, prompt always
+      --one-file-system  when removing recursively, refuse a directory that
+                          contains another file system
+      --no-preserve-root  do not treat '/', protected paths or mount points
+                          specially
+      --preserve-root[=all]  do not remove '/' (default); with 'all', reject
+                          any argument on a separate device from its parent
+  -r, -R, --recursive   remove directories and their contents
+  -d, --dir             remove empty directories
+  -v, --verbose         explain what is being done
+      --help            display this help and exit
+      --version         output version information and exit
+
+Everything removed can be brought back with 'toss restore'.
+`
+
+// rmCompatArgs reports whether toss should behave as rm, because it was run
+// as rm or with --rm-compat as its first argument, and returns the
+// program name to use in messages and rm's arguments.
+func rmCompatArgs(argv []string) (string, []string, bool) {
+	prog := filepath.Base(argv[0])
+	switch {
+	case prog == "rm":
+		return prog, argv[1:], true
+	case len(argv) > 1 && argv[1] == "--rm-compat":
+		return prog, argv[2:], true
+	}
+	return "", nil, false
+}
+
+// runRm does what rm would with args, moving files to the bin instead of
+// deleting them, and returns rm's exit status: 0 if everything asked for
+// was removed (or declined at a prompt), 1 otherwise.
+func runRm(prog string, args []string) int {
+	opts, operands, err := rmcompat.Parse(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\nTry '%s --help' for more information.\n", prog, err, prog)
+		return 1
+	}
+	switch {
+	case opts.Help:
+		fmt.Printf(rmHelp, prog)
+		return 0
+	case opts.Version:
+		fmt.Printf("%s (toss) in rm compatibility mode\n", prog)
+		return 0
+	case len(operands) == 0:
+		if opts.Force {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "%s: missing operand\nTry '%s --help' for more information.\n", prog, prog)
+		return 1
+	}
+
+	r := &rm{prog: prog, opts: opts, in: bufio.NewReader(os.Stdin), tossed: make(map[string]bool)}
+	if opts.PromptOnce && (len(operands) > 3 || opts.Recursive) {
+		plural, how := "s", ""
+		if len(operands) == 1 {
+			plural = ""
+		}
+		if opts.Recursive {
+			how = " recursively"
+		}
+		if !r.ask("remove %d argument%s%s? ", len(operands), plural, how) {
+			return 0
+		}
+	}
+
+	b, database, err := openBin()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", prog, err)
+		return 1
+	}
+	defer database.Close()
+	guard, err := newGuard(b, database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", prog, err)
+		return 1
+	}
+	r.b, r.d, r.guard = b, database, guard
+
+	status := 0
+	for _, op := range operands {
+		if !r.remove(op) {
+			status = 1
+		}
+	}
+	autoGC(b, database, r.tossed, nil)
+	return status
+}
+
+type rm struct {
+	prog  string
+	opts  rmcompat.Options
+	in    *bufio.Reader
+	b     bin.Backend
+	d     *sql.DB
+	guard *bin.Guard
+
+	tossed map[string]bool // IDs of the items tossed so far
+}
+
+// remove tosses op as rm would remove it, reporting whether it succeeded.
+// Declining a prompt counts as success.
+func (r *rm) remove(op string) bool {
+	if base := filepath.Base(op); base == "." || base == ".." {
+		r.fail("refusing to remove '.' or '..' directory: skipping %s", quote(op))
+		return false
+	}
+	info, err := os.Lstat(op)
+	if err != nil {
+		if r.opts.Force && errors.Is(err, fs.ErrNotExist) {
+			return true
+		}
+		r.fail("cannot remove %s: %s", quote(op), errText(err))
+		return false
+	}
+	abs, err := filepath.Abs(op)
+	if err != nil {
+		r.fail("cannot remove %s: %s", quote(op), errText(err))
+		return false
+	}
+
+	if info.IsDir() {
+		if r.opts.Recursive && abs == "/" && r.opts.PreserveRoot {
+			r.fail("it is dangerous to operate recursively on %s", quote(op))
+			r.fail("use --no-preserve-root to override this failsafe")
+			return false
+		}
+		if !r.opts.Recursive {
+			if !r.opts.Dir {
+				r.fail("cannot remove %s: Is a directory", quote(op))
+				return false
+			}
+			if entries, err := os.ReadDir(op); err != nil || len(entries) > 0 {
+				r.fail("cannot remove %s: Directory not empty", quote(op))
+				return false
+			}
+		}
+		if r.opts.PreserveRootAll && otherDevice(filepath.Dir(abs), info) {
+			r.fail("skipping %s, since it's on a different device", quote(op))
+			r.fail("and --preserve-root=all is in effect")
+			return false
+		}
+		if r.opts.OneFileSystem {
+			if mount := mountInside(op, info); mount != "" {
+				r.fail("skipping %s, since it's on a different device", quote(mount))
+				r.fail("cannot remove %s: Directory not empty", quote(op))
+				return false
+			}
+		}
+	}
+
+	// --no-preserve-root also lifts toss's own protections.
+	if err := r.guard.Check(op, !r.opts.PreserveRoot); err != nil {
+		var refusal *bin.Refusal
+		if !errors.As(err, &refusal) {
+			r.fail("cannot remove %s: %v", quote(op), err)
+			return false
+		}
+		r.fail("refusing to remove %s: %s", quote(op), refusal.Reason)
+		if refusal.Insistable {
+			r.fail("use --no-preserve-root to override this failsafe")
+		}
+		return false
+	}
+
+	switch {
+	case r.opts.Prompt == rmcompat.PromptAlways:
+		if !r.ask("remove %s %s? ", fileType(info), quote(op)) {
+			return true
+		}
+	case r.opts.Prompt == rmcompat.PromptDefault && writeProtected(op, info) && ui.IsTerminal(os.Stdin):
+		if !r.ask("remove write-protected %s %s? ", fileType(info), quote(op)) {
+			return true
+		}
+	}
+
+	e, err := bin.TossItem(r.b, r.d, op)
+	if e.ID != "" {
+		r.tossed[e.ID] = true
+	}
+	if err != nil {
+		r.fail("cannot remove %s: %s", quote(op), errText(err))
+		return false
+	}
+	if r.opts.Verbose {
+		if info.IsDir() {
+			fmt.Printf("removed directory %s\n", quote(op))
+		} else {
+			fmt.Printf("removed %s\n", quote(op))
+		}
+	}
+	return true
+}
+
+func (r *rm) fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", r.prog, fmt.Sprintf(format, args...))
+}
+
+// ask prompts on stderr, as rm does, and reads a yes or no from stdin.
+func (r *rm) ask(format string, args ...any) bool {
+	fmt.Fprintf(os.Stderr, "%s: %s", r.prog, fmt.Sprintf(format, args...))
+	line, _ := r.in.ReadString('\n')
+	return strings.HasPrefix(strings.TrimSpace(line), "y") || strings.HasPrefix(strings.TrimSpace(line), "Y")
+}
+
+// fileType names the kind of file as rm's prompts do.
+func fileType(info fs.FileInfo) string {
+	switch m := info.Mode(); {
+	case m.IsRegular() && info.Size() == 0:
+		return "regular empty file"
+	case m.IsRegular():
+		return "regular file"
+	case m.IsDir():
+		return "directory"
+	case m&fs.ModeSymlink != 0:
+		return "symbolic link"
+	case m&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case m&fs.ModeSocket != 0:
+		return "socket"
+	case m&fs.ModeCharDevice != 0:
+		return "character special file"
+	case m&fs.ModeDevice != 0:
+		return "block special file"
+	}
+	return "file"
+}
+
+// writeProtected reports whether rm would ask before removing path: it is
+// not a symlink and we may not write to it.
+func writeProtected(path string, info fs.FileInfo) bool {
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return false
+	}
+	return unix.Faccessat(unix.AT_FDCWD, path, unix.W_OK, unix.AT_EACCESS) != nil
+}
+
+// otherDevice reports whether info is on a different device from the
+// directory dir.
+func otherDevice(dir string, info fs.FileInfo) bool {
+	parent, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	return device(parent) != device(info)
+}
+
+// mountInside returns a directory below root on another file system than
+// root, or "" if there is none.
+func mountInside(root string, info fs.FileInfo) string {
+	dev := device(info)
+	found := ""
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && device(info) != dev {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}
+
+func device(info fs.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev)
+	}
+	return 0
+}
+
+// quote quotes a file name for a message the way rm does in the common
+// cases.
+func quote(name string) string {
+	if strings.Contains(name, "'") {
+		return `"` + name + `"`
+	}
+	return "'" + name + "'"
+}
+
+// errText is the system's description of err, capitalised like rm's
+// messages: "No such file or directory".
+func errText(err error) string {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return err.Error()
+	}
+	s := []rune(errno.Error())
+	s[0] = unicode.ToUpper(s[0])
+	return string(s)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rmStatus runs toss as rm with args and returns its exit status and what
+// it wrote to stderr.
+func rmStatus(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	stderr := os.Stderr
+	out, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	defer out.Close()
+	os.Stderr = out
+	defer func() {
+		os.Stderr = stderr
+		if binLock != nil {
+			binLock.Close()
+			binLock = nil
+		}
+	}()
+
+	status := runRm("rm", args)
+
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	msg, err := io.ReadAll(out)
+	if err != nil {
+		t.Fatalf("reading stderr: %v", err)
+	}
+	return status, string(msg)
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func TestRunRm(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   func(work string) []string
+		status int
+		stderr string   // a part of the message, or "" for none at all
+		gone   []string // relative to work
+		kept   []string
+	}{
+		{
+			name:   "file",
+			args:   func(w string) []string { return []string{filepath.Join(w, "f.txt")} },
+			status: 0,
+			gone:   []string{"f.txt"},
+		},
+		{
+			name:   "missing operand",
+			args:   func(w string) []string { return nil },
+			status: 1,
+			stderr: "missing operand",
+		},
+		{
+			name:   "-f with missing operand",
+			args:   func(w string) []string { return []string{"-f"} },
+			status: 0,
+		},
+		{
+			name:   "nonexistent",
+			args:   func(w string) []string { return []string{filepath.Join(w, "nope")} },
+			status: 1,
+			stderr: "No such file or directory",
+		},
+		{
+			name:   "-f with nonexistent",
+			args:   func(w string) []string { return []string{"-f", filepath.Join(w, "nope"), filepath.Join(w, "f.txt")} },
+			status: 0,
+			gone:   []string{"f.txt"},
+		},
+		{
+			name:   "directory without -r",
+			args:   func(w string) []string { return []string{filepath.Join(w, "full")} },
+			status: 1,
+			stderr: "Is a directory",
+			kept:   []string{"full/g.txt"},
+		},
+		{
+			name:   "-d on an empty directory",
+			args:   func(w string) []string { return []string{"-d", filepath.Join(w, "empty")} },
+			status: 0,
+			gone:   []string{"empty"},
+		},
+		{
+			name:   "-d on a non-empty directory",
+			args:   func(w string) []string { return []string{"-d", filepath.Join(w, "full")} },
+			status: 1,
+			stderr: "Directory not empty",
+			kept:   []string{"full/g.txt"},
+		},
+		{
+			name:   "-r on a non-empty directory",
+			args:   func(w string) []string { return []string{"-r", filepath.Join(w, "full")} },
+			status: 0,
+			gone:   []string{"full"},
+		},
+		{
+			name:   ".",
+			args:   func(w string) []string { return []string{"-r", w + "/full/."} },
+			status: 1,
+			stderr: "refusing to remove '.' or '..' directory",
+			kept:   []string{"full/g.txt"},
+		},
+		{
+			name:   "..",
+			args:   func(w string) []string { return []string{"-r", w + "/full/.."} },
+			status: 1,
+			stderr: "refusing to remove '.' or '..' directory",
+			kept:   []string{"full/g.txt"},
+		},
+		{
+			name:   "--preserve-root",
+			args:   func(w string) []string { return []string{"-r", "/"} },
+			status: 1,
+			stderr: "it is dangerous to operate recursively on '/'",
+		},
+		{
+			name:   "one failure fails the run",
+			args:   func(w string) []string { return []string{filepath.Join(w, "f.txt"), filepath.Join(w, "nope")} },
+			status: 1,
+			stderr: "cannot remove",
+			gone:   []string{"f.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := setHome(t)
+			work := filepath.Join(home, "work")
+			writeFile(t, filepath.Join(work, "f.txt"), "f")
+			writeFile(t, filepath.Join(work, "full", "g.txt"), "g")
+			if err := os.Mkdir(filepath.Join(work, "empty"), 0755); err != nil {
+				t.Fatalf("Mkdir: %v", err)
+			}
+
+			status, stderr := rmStatus(t, tt.args(work)...)
+			if status != tt.status {
+				t.Errorf("exit status: want %d, got %d (%s)", tt.status, status, stderr)
+			}
+			switch {
+			case tt.stderr == "" && stderr != "":
+				t.Errorf("want no message, got %q", stderr)
+			case !strings.Contains(stderr, tt.stderr):
+				t.Errorf("message: want %q in %q", tt.stderr, stderr)
+			}
+			for _, path := range tt.gone {
+				if exists(filepath.Join(work, path)) {
+					t.Errorf("%s: should have been tossed", path)
+				}
+			}
+			for _, path := range tt.kept {
+				if !exists(filepath.Join(work, path)) {
+					t.Errorf("%s: should have been kept", path)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,15 @@
 package cmd
 
 import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/lock"
 	"github.com/spf13/cobra"
 )
 
@@ -8,7 +17,10 @@ var rootCmd = &cobra.Command{
 	Use:   "toss <file...>",
 	Short: "A safer rm — moves files to ~/.toss/ instead of deleting them",
 	Long: `toss moves files and directories to ~/.toss/files/ instead of permanently
-deleting them. Files can be restored to their original location with 'toss restore'.`,
+deleting them. Files can be restored to their original location with 'toss restore'.
+
+Run as rm (through a symlink named rm), or with --rm-compat as the first
+argument, toss accepts rm's options and behaves like rm: see 'toss --rm-compat --help'.`,
 	SilenceUsage: true,
 	Args:         cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -19,12 +31,108 @@ deleting them. Files can be restored to their original location with 'toss resto
 	},
 }
 
+// binLock is held shared by every command for as long as it runs, and
+// exclusively by those that work on the whole bin at once.
+var binLock *lock.File
+
+// openBin loads the configured backend and opens its index, after
+// recovering any interrupted operation and syncing with the bin.
+func openBin() (bin.Backend, *sql.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := bin.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A dry run reads the index as it stands: it neither locks, recovers,
+	// migrates nor syncs, as all of those write.
+	if dryRun {
+		database, err := db.OpenReadOnly(b.DBPath())
+		if err != nil {
+			return nil, nil, err
+		}
+		if ops, err := db.Pending(database); err == nil && len(ops) > 0 {
+			fmt.Fprintf(os.Stderr, "toss: %d interrupted operation(s) would be recovered first\n", len(ops))
+		}
+		return b, database, nil
+	}
+
+	database, err := db.Open(b.DBPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := lockBin(b, database); err != nil {
+		database.Close()
+		return nil, nil, err
+	}
+
+	if err := b.Sync(database); err != nil {
+		database.Close()
+		return nil, nil, fmt.Errorf("syncing bin: %w", err)
+	}
+	return b, database, nil
+}
+
+// lockBin takes the shared bin lock. If no other toss is running it first
+// recovers interrupted operations; otherwise a pending operation may belong
+// to a process still working on it, and recovery waits for a later run.
+func lockBin(b bin.Backend, d *sql.DB) error {
+	l, err := lock.Open(b.DBPath() + ".lock")
+	if err != nil {
+		return err
+	}
+	alone, err := l.TryExclusive()
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("locking bin: %w", err)
+	}
+	if alone {
+		notes, err := bin.Recover(b, d)
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "toss: recovered: %s\n", note)
+		}
+		if err != nil {
+			l.Close()
+			return err
+		}
+	}
+	if err := l.Shared(); err != nil {
+		l.Close()
+		return fmt.Errorf("locking bin: %w", err)
+	}
+	binLock = l
+	return nil
+}
+
+// lockBinExclusive waits until no other toss is working on the bin.
+func lockBinExclusive() error {
+	if err := binLock.Exclusive(); err != nil {
+		return fmt.Errorf("locking bin: %w", err)
+	}
+	return nil
+}
+
 func Execute() error {
+	if prog, args, ok := rmCompatArgs(os.Args); ok {
+		os.Exit(runRm(prog, args))
+	}
 	return rootCmd.Execute()
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be moved, deleted and recorded without doing it")
+	rootCmd.Flags().Bool("no-preserve-root", false, "toss protected paths such as /etc or mount points anyway")
+	rootCmd.Flags().BoolP("yes", "y", false, "toss without asking, however much is tossed")
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(restoreCmd)
 	rootCmd.AddCommand(emptyCmd)
+
+	bin.Warn = func(path string, lost []string) {
+		fmt.Fprintf(os.Stderr, "toss: %s: could not preserve %s\n", path, strings.Join(lost, ", "))
+	}
 }
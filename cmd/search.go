@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/roman91DE/toss/internal/search"
+	"github.com/roman91DE/toss/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query...>",
+	Short: "Find tossed items by fuzzy, glob or regex matching",
+	Long: `search finds tossed items whose original path matches the query. By
+default the query matches fuzzily: its characters must appear in the path in
+order, and the best matches, such as those starting words, come first.
+--substring, --glob and --regex match it in those ways instead.
+
+The query can be narrowed with qualifiers:
+
+  dir:D       items from directory D or below it; a D that is not a path
+              (starting with /, ~ or .) is matched as part of the directory
+  name:N      N is matched, in the chosen mode, against the file name only
+  before:T    items tossed before T (e.g. 2026-02-18, "2026-02-18 10:30", 7d)
+  after:T     items tossed at or after T
+  size>S      items larger than S (e.g. 100MB)
+  size<S      items smaller than S
+
+Several arguments are joined into one query.`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, err := searchMode(cmd, search.Fuzzy)
+		if err != nil {
+			return err
+		}
+		format, _ := cmd.Flags().GetString("format")
+		limit, _ := cmd.Flags().GetInt("limit")
+		q, err := search.Parse(strings.Join(args, " "), time.Now())
+		if err != nil {
+			return err
+		}
+
+		_, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		entries, err := search.Find(database, q, mode)
+		if err != nil {
+			return err
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+
+		if format != "table" {
+			return ui.WriteEntries(os.Stdout, entries, format)
+		}
+		if len(entries) == 0 {
+			fmt.Println("no matching items")
+			return nil
+		}
+		ui.PrintTable(entries)
+		return nil
+	},
+}
+
+// searchMode returns the mode chosen by the flags added by addSearchFlags,
+// or def if none was given.
+func searchMode(cmd *cobra.Command, def string) (string, error) {
+	mode := ""
+	for _, m := range search.Modes {
+		if set, _ := cmd.Flags().GetBool(m); set {
+			if mode != "" {
+				return "", fmt.Errorf("--%s and --%s are mutually exclusive", mode, m)
+			}
+			mode = m
+		}
+	}
+	if mode == "" {
+		mode = def
+	}
+	return mode, nil
+}
+
+func addSearchFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(search.Substring, false, "match the query as a case-insensitive part of the path")
+	cmd.Flags().Bool(search.Fuzzy, false, "match the query's characters in order, best matches first")
+	cmd.Flags().Bool(search.Glob, false, "match the query as a glob against the whole path (* also matches /)")
+	cmd.Flags().Bool(search.Regex, false, "match the query as a regular expression against the path")
+}
+
+func init() {
+	searchCmd.Flags().String("format", "table", "output format: table, "+strings.Join(ui.Formats, ", "))
+	searchCmd.Flags().IntP("limit", "n", 0, "show at most this many items")
+	addSearchFlags(searchCmd)
+	rootCmd.AddCommand(searchCmd)
+}
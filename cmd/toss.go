@@ -1,55 +1,99 @@
 package cmd
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
 	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 func runToss(cmd *cobra.Command, args []string) error {
-	binDir, dbPath, err := bin.Paths()
+	insist, _ := cmd.Flags().GetBool("no-preserve-root")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	b, database, err := openBin()
 	if err != nil {
 		return err
 	}
+	defer database.Close()
 
-	home, _ := os.UserHomeDir()
-	tossDirAbs, _ := filepath.Abs(filepath.Join(home, ".toss"))
-
-	database, err := db.Open(dbPath)
+	guard, err := newGuard(b, database)
 	if err != nil {
 		return err
 	}
-	defer database.Close()
 
+	// Refusals and missing paths come first, so that only what would
+	// really be tossed is measured, and refused paths such as / never are.
 	var hadError bool
+	var targets []string
 	for _, arg := range args {
-		abs, err := filepath.Abs(arg)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "toss: %s: %v\n", arg, err)
+		if err := guard.Check(arg, insist); err != nil {
+			fmt.Fprintf(os.Stderr, "toss: %v\n", err)
+			var refusal *bin.Refusal
+			if errors.As(err, &refusal) && refusal.Insistable {
+				fmt.Fprintf(os.Stderr, "toss: use --no-preserve-root to override this failsafe\n")
+			}
 			hadError = true
 			continue
 		}
-
-		// Refuse to toss the bin itself
-		if abs == tossDirAbs {
-			fmt.Fprintf(os.Stderr, "toss: refusing to toss the bin directory itself\n")
+		if _, err := os.Lstat(arg); err != nil {
+			fmt.Fprintf(os.Stderr, "toss: %s: %v\n", arg, err)
 			hadError = true
 			continue
 		}
+		targets = append(targets, arg)
+	}
 
-		entry, err := bin.Move(arg, binDir)
+	if len(targets) > 0 && !yes {
+		ok, err := confirmLarge(targets)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "toss: %v\n", err)
+			return err
+		}
+		if !ok {
+			fmt.Println("aborted")
+			if hadError {
+				os.Exit(1)
+			}
+			return nil
+		}
+	}
+
+	tossed := make(map[string]bool)
+	var planned []db.Entry
+	for _, arg := range targets {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "toss: %s: %v\n", arg, err)
 			hadError = true
 			continue
 		}
 
-		if err := db.Append(database, entry); err != nil {
-			fmt.Fprintf(os.Stderr, "toss: recording %s: %v\n", arg, err)
+		if dryRun {
+			e, err := planToss(b, arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "toss: %v\n", err)
+				hadError = true
+				continue
+			}
+			tossed[e.ID] = true
+			planned = append(planned, e)
+			continue
+		}
+
+		e, err := bin.TossItem(b, database, arg)
+		if e.ID != "" {
+			tossed[e.ID] = true
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "toss: %v\n", err)
 			hadError = true
 			continue
 		}
@@ -57,8 +101,83 @@ func runToss(cmd *cobra.Command, args []string) error {
 		fmt.Printf("tossed: %s\n", abs)
 	}
 
+	autoGC(b, database, tossed, planned)
+
 	if hadError {
 		os.Exit(1)
 	}
 	return nil
 }
+
+// confirmLarge asks before a toss big enough to be a mistake, such as a
+// glob that matched far more than meant, and reports whether to go ahead.
+// A dry run only says that it would ask, and why.
+func confirmLarge(args []string) (bool, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return false, err
+	}
+	th := cfg.Confirm
+	t := bin.Measure(args, th.Size > 0 || th.Files > 0)
+	reasons := largeToss(t, th)
+	if len(reasons) == 0 {
+		return true, nil
+	}
+
+	if dryRun {
+		fmt.Printf("would ask before tossing %d item(s), %s in %d file(s):\n", t.Paths, ui.FormatSize(t.Size), t.Files)
+	} else {
+		fmt.Printf("About to toss %d item(s), %s in %d file(s):\n", t.Paths, ui.FormatSize(t.Size), t.Files)
+	}
+	for _, r := range reasons {
+		fmt.Printf("  %s\n", r)
+	}
+	if dryRun {
+		return true, nil
+	}
+	ok, err := ui.Confirm("Toss them?")
+	if errors.Is(err, io.EOF) {
+		fmt.Println()
+		return false, errors.New("no answer; use --yes to toss without asking")
+	}
+	return ok, err
+}
+
+// largeToss describes each way t goes past th.
+func largeToss(t bin.Tally, th config.Thresholds) []string {
+	var reasons []string
+	if th.Args > 0 && t.Paths > th.Args {
+		reason := fmt.Sprintf("more than %d items", th.Args)
+		if t.Siblings > th.Args {
+			reason += fmt.Sprintf(", %d of them in %s (a glob?)", t.Siblings, t.Parent)
+		}
+		reasons = append(reasons, reason)
+	}
+	if th.Size > 0 && t.Size > th.Size {
+		reasons = append(reasons, fmt.Sprintf("more than %s in all", ui.FormatSize(th.Size)))
+	}
+	if th.Files > 0 && t.Dense > th.Files {
+		reasons = append(reasons, fmt.Sprintf("%s holds %d files, more than %d", t.Crowded, t.Dense, th.Files))
+	}
+	return reasons
+}
+
+// newGuard returns the guard for the configured protected paths.
+func newGuard(b bin.Backend, d *sql.DB) (*bin.Guard, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return bin.NewGuard(b, d, cfg)
+}
+
+// autoGC applies the retention policy after tossing, if so configured,
+// sparing the items just tossed, whose IDs are in tossed. planned holds
+// the entries a dry run would have tossed.
+func autoGC(b bin.Backend, d *sql.DB, tossed map[string]bool, planned []db.Entry) {
+	if cfg, err := config.Load(); err == nil && cfg.AutoGC && !cfg.Retention.IsZero() {
+		if _, _, err := collect(b, d, cfg.Retention, dryRun, tossed, planned); err != nil {
+			fmt.Fprintf(os.Stderr, "toss: gc: %v\n", err)
+		}
+	}
+}
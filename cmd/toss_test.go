@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/roman91DE/toss/internal/bin"
+	"github.com/roman91DE/toss/internal/config"
+)
+
+func TestLargeToss(t *testing.T) {
+	th := config.Thresholds{Args: 3, Size: 1000, Files: 10}
+	tests := []struct {
+		name  string
+		tally bin.Tally
+		th    config.Thresholds
+		want  []string
+	}{
+		{"small", bin.Tally{Paths: 3, Size: 1000, Files: 10, Dense: 10}, th, nil},
+		{"many args", bin.Tally{Paths: 4, Parent: "/a", Siblings: 2}, th,
+			[]string{"more than 3 items"}},
+		{"glob", bin.Tally{Paths: 5, Parent: "/a", Siblings: 5}, th,
+			[]string{"more than 3 items, 5 of them in /a (a glob?)"}},
+		{"big", bin.Tally{Paths: 1, Size: 1001}, th,
+			[]string{"more than 1000B in all"}},
+		{"crowded dir", bin.Tally{Paths: 1, Files: 11, Crowded: "/a/b", Dense: 11}, th,
+			[]string{"/a/b holds 11 files, more than 10"}},
+		{"files spread out", bin.Tally{Paths: 2, Files: 20, Crowded: "/a/b", Dense: 10}, th, nil},
+		{"everything", bin.Tally{Paths: 4, Size: 2000, Files: 11, Crowded: "/a/b", Dense: 11}, th,
+			[]string{"more than 3 items", "more than 1000B in all", "/a/b holds 11 files, more than 10"}},
+		{"disabled", bin.Tally{Paths: 4, Size: 2000, Files: 11, Dense: 11}, config.Thresholds{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := largeToss(tt.tally, tt.th); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("largeToss: want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore everything the last toss command tossed",
+	Long: `undo restores, in one go, every item tossed by the most recent run of toss
+(or of toss as rm). --steps N undoes the last N runs, newest first. Items
+from those runs that were already restored or purged are left out; a run
+with nothing left still counts, and is reported as already undone. When
+something is already at an item's original path, undo asks what to do
+unless --rename, --skip, --overwrite or --merge decides.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps, _ := cmd.Flags().GetInt("steps")
+		if steps < 1 {
+			return fmt.Errorf("--steps must be at least 1")
+		}
+		collision, err := collisionFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		b, database, err := openBin()
+		if err != nil {
+			return err
+		}
+		defer database.Close()
+
+		batches, err := db.LastBatches(database, steps)
+		if err != nil {
+			return err
+		}
+		if len(batches) == 0 {
+			return fmt.Errorf("nothing to undo")
+		}
+		if len(batches) < steps {
+			fmt.Fprintf(os.Stderr, "toss: only %d toss run(s) left to undo\n", len(batches))
+		}
+
+		// A run whose items have all left the bin was already undone, by
+		// undo or by hand; it still counts as one of the steps.
+		items := make([][]db.Entry, len(batches))
+		undone := 0
+		for i, batch := range batches {
+			if items[i], err = db.FindByBatch(database, batch.ID); err != nil {
+				return err
+			}
+			if len(items[i]) == 0 {
+				undone++
+			}
+		}
+		switch {
+		case undone == 1 && len(batches) == 1:
+			return fmt.Errorf("the last run was already undone")
+		case undone == len(batches):
+			return fmt.Errorf("the last %d runs were already undone", undone)
+		}
+
+		restored, failed := 0, 0
+		for i, batch := range batches {
+			entries := items[i]
+			command := batch.Command
+			if command == "" {
+				command = "toss"
+			}
+			when := batch.TossedAt.Format("2006-01-02 15:04")
+			if len(entries) == 0 {
+				fmt.Printf("already undone: %s (%s)\n", command, when)
+				continue
+			}
+			fmt.Printf("undoing: %s (%s, %d item(s))\n", command, when, len(entries))
+
+			for _, entry := range entries {
+				if dryRun {
+					planRestore(b, entry, entry.OriginalPath, collision)
+					continue
+				}
+				dest, err := restoreEntry(b, database, entry, entry.OriginalPath, collision)
+				switch {
+				case err != nil:
+					fmt.Fprintf(os.Stderr, "toss: restoring %s: %v\n", entry.OriginalPath, err)
+					failed++
+				case dest == "":
+					fmt.Printf("skipped: %s\n", entry.OriginalPath)
+				case dest != entry.OriginalPath:
+					fmt.Printf("restored: %s -> %s\n", entry.OriginalPath, dest)
+					restored++
+				default:
+					fmt.Printf("restored: %s\n", entry.OriginalPath)
+					restored++
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d item(s) restored, %d failed", restored, failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	undoCmd.Flags().Int("steps", 1, "undo this many of the most recent toss runs")
+	addCollisionFlags(undoCmd)
+	rootCmd.AddCommand(undoCmd)
+}
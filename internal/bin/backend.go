@@ -0,0 +1,246 @@
+package bin
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// Backend is a storage layout for tossed items. The database at DBPath is
+// the index every command works from; Sync reconciles it with whatever
+// changed on disk behind toss's back. Prepare picks, and if need be
+// reserves, the place for an item in the bin; the move itself is done by
+// TossItem so that it can be journaled. Preview returns the entry Prepare
+// would, without creating or reserving anything.
+type Backend interface {
+	Root() string
+	Dir() string
+	DBPath() string
+	Bins(d *sql.DB) ([]string, error)
+	Path(e db.Entry) string
+	Prepare(src string) (db.Entry, error)
+	Preview(src string) (db.Entry, error)
+	Restore(e db.Entry, dest string) error
+	Merge(e db.Entry, dest string) error
+	Delete(e db.Entry) error
+	Empty(binDir string) error
+	Sync(d *sql.DB) error
+	Adopt(binDir, name string) (db.Entry, error)
+}
+
+func New(cfg config.Config) (Backend, error) {
+	switch cfg.Backend {
+	case config.BackendFreedesktop:
+		trashDir, err := TrashDir()
+		if err != nil {
+			return nil, err
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("finding home dir: %w", err)
+		}
+		return &freedesktopBackend{
+			trashDir: trashDir,
+			dbPath:   filepath.Join(home, ".toss", "trash.db"),
+		}, nil
+	case config.BackendToss, "":
+		binDir, dbPath, err := Paths()
+		if err != nil {
+			return nil, err
+		}
+		return &tossBackend{binDir: binDir, dbPath: dbPath}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", cfg.Backend)
+	}
+}
+
+// entryDir returns the bin holding e. Entries recorded before per-filesystem
+// bins existed have no BinDir and live in the home bin.
+func entryDir(e db.Entry, home string) string {
+	if e.BinDir != "" {
+		return e.BinDir
+	}
+	return home
+}
+
+// tossBackend is the native ~/.toss/files layout, where the database is the
+// only record of each item. Items on other filesystems go to
+// $topdir/.toss-$uid/files so tossing them stays a rename.
+type tossBackend struct {
+	binDir string
+	dbPath string
+}
+
+func (b *tossBackend) Root() string   { return filepath.Dir(b.binDir) }
+func (b *tossBackend) Dir() string    { return b.binDir }
+func (b *tossBackend) DBPath() string { return b.dbPath }
+
+// Bins returns the home bin, the bins the index knows about and any bin of
+// ours at the top of a mounted filesystem.
+func (b *tossBackend) Bins(d *sql.DB) ([]string, error) {
+	bins, err := knownBins(d, b.binDir)
+	if err != nil {
+		return nil, err
+	}
+	return withMountBins(bins, func(point string) []string {
+		return []string{filepath.Join(point, fmt.Sprintf(".toss-%d", os.Getuid()), "files")}
+	})
+}
+
+func (b *tossBackend) Path(e db.Entry) string {
+	return filepath.Join(entryDir(e, b.binDir), e.BinName)
+}
+
+func (b *tossBackend) Prepare(src string) (db.Entry, error) {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return db.Entry{}, fmt.Errorf("resolving path: %w", err)
+	}
+	return prepare(src, b.binFor(abs, true))
+}
+
+func (b *tossBackend) Preview(src string) (db.Entry, error) {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return db.Entry{}, fmt.Errorf("resolving path: %w", err)
+	}
+	return newEntry(src, b.binFor(abs, false))
+}
+
+// binFor picks the bin for abs, creating it if create is set. Otherwise
+// nothing is touched, and the bin is the one that would be created.
+func (b *tossBackend) binFor(abs string, create bool) string {
+	own := mayOwn
+	if create {
+		if err := EnsureDirs(b.binDir); err != nil {
+			return b.binDir
+		}
+		own = ownedDir
+	}
+	topdir, ok := foreignTopdir(abs, b.binDir)
+	if !ok {
+		return b.binDir
+	}
+	root := filepath.Join(topdir, fmt.Sprintf(".toss-%d", os.Getuid()))
+	if !own(root) {
+		return b.binDir
+	}
+	return filepath.Join(root, "files")
+}
+
+func (b *tossBackend) Restore(e db.Entry, dest string) error {
+	dir := entryDir(e, b.binDir)
+	if err := RestoreTo(e, dir, dest); err != nil {
+		return err
+	}
+	return removeSidecar(dir, e.BinName)
+}
+
+func (b *tossBackend) Merge(e db.Entry, dest string) error {
+	dir := entryDir(e, b.binDir)
+	if err := MergeTo(e, dir, dest); err != nil {
+		return err
+	}
+	return removeSidecar(dir, e.BinName)
+}
+
+func (b *tossBackend) Delete(e db.Entry) error {
+	if err := os.RemoveAll(b.Path(e)); err != nil {
+		return err
+	}
+	return removeSidecar(entryDir(e, b.binDir), e.BinName)
+}
+
+func (b *tossBackend) Empty(binDir string) error {
+	if err := os.RemoveAll(infoDir(binDir)); err != nil {
+		return fmt.Errorf("removing sidecars: %w", err)
+	}
+	return Empty(binDir)
+}
+
+func (b *tossBackend) Sync(d *sql.DB) error {
+	return nil
+}
+
+// Adopt builds an entry for an item found in binDir without one, from its
+// sidecar if it has one. Otherwise the ID is recovered from the UUID prefix
+// of the name, and as the original directory is unknown the item is placed
+// at the top of the bin's filesystem (the home directory for the home bin).
+func (b *tossBackend) Adopt(binDir, name string) (db.Entry, error) {
+	path := filepath.Join(binDir, name)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	if e, err := readSidecar(binDir, name); err == nil {
+		e.BinName = name
+		e.BinDir = binDir
+		e.IsDir = info.IsDir()
+		e.SizeBytes = itemSize(path, info)
+		return e, nil
+	}
+	id, base, ok := splitBinName(name)
+	if !ok {
+		id, base = db.NewID(), name
+	}
+	tossedAt, err := changeTime(path)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	e := db.Entry{
+		ID:           id,
+		OriginalPath: filepath.Join(filepath.Dir(filepath.Dir(binDir)), base),
+		BinName:      name,
+		BinDir:       binDir,
+		TossedAt:     tossedAt,
+		IsDir:        info.IsDir(),
+		SizeBytes:    itemSize(path, info),
+	}
+	setFileMeta(&e, path, info)
+	return e, nil
+}
+
+// knownBins returns the home bin followed by every other bin the index
+// has recorded an item in.
+func knownBins(d *sql.DB, home string) ([]string, error) {
+	dirs, err := db.BinDirs(d)
+	if err != nil {
+		return nil, err
+	}
+	bins := []string{home}
+	for _, dir := range dirs {
+		if dir != home {
+			bins = append(bins, dir)
+		}
+	}
+	return bins, nil
+}
+
+// withMountBins appends to bins those of candidates(mountPoint) that exist
+// as directories on some mounted filesystem.
+func withMountBins(bins []string, candidates func(point string) []string) ([]string, error) {
+	seen := make(map[string]bool, len(bins))
+	for _, dir := range bins {
+		seen[dir] = true
+	}
+	points, err := mounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		for _, dir := range candidates(point) {
+			if seen[dir] {
+				continue
+			}
+			if info, err := os.Lstat(dir); err == nil && info.IsDir() {
+				seen[dir] = true
+				bins = append(bins, dir)
+			}
+		}
+	}
+	return bins, nil
+}
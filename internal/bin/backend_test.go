@@ -0,0 +1,52 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// moveInto tosses src into b without a journal.
+func moveInto(b Backend, src string) (db.Entry, error) {
+	e, err := b.Prepare(src)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	return e, moveItem(e.OriginalPath, b.Path(e))
+}
+
+func TestTossBackend_PreviewTouchesNothing(t *testing.T) {
+	dir := t.TempDir()
+	b := &tossBackend{binDir: filepath.Join(dir, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+	src := filepath.Join(dir, "file.txt")
+	writeFile(t, src, "xyz", 0644)
+	e, err := b.Preview(src)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if e.BinDir != b.binDir || e.OriginalPath != src || e.SizeBytes != 3 {
+		t.Errorf("Preview: got %+v", e)
+	}
+	if _, err := os.Lstat(b.binDir); !os.IsNotExist(err) {
+		t.Errorf("Preview created the bin: %v", err)
+	}
+}
+
+func TestTossBackend_DeleteDirectory(t *testing.T) {
+	dir := t.TempDir()
+	b := &tossBackend{binDir: filepath.Join(dir, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+	src := filepath.Join(dir, "tree")
+	writeFile(t, filepath.Join(src, "sub", "f.txt"), "x", 0644)
+	entry, err := moveInto(b, src)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if err := b.Delete(entry); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Lstat(b.Path(entry)); !os.IsNotExist(err) {
+		t.Error("directory should be gone after Delete")
+	}
+}
@@ -3,7 +3,6 @@ package bin
 import (
 	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -29,12 +28,19 @@ func EnsureDirs(binDir string) error {
 }
 
 func dirSize(path string) (int64, error) {
-	var size int64
-	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+	size, _, err := dirUsage(path)
+	return size, err
+}
+
+// dirUsage returns the total size of the files below path and how many
+// there are.
+func dirUsage(path string) (size int64, files int, err error) {
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
 		if !d.IsDir() {
+			files++
 			info, err := d.Info()
 			if err == nil {
 				size += info.Size()
@@ -42,14 +48,38 @@ func dirSize(path string) (int64, error) {
 		}
 		return nil
 	})
-	return size, err
+	return size, files, err
 }
 
 func Move(src, binDir string) (db.Entry, error) {
+	entry, err := prepare(src, binDir)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	if err := moveItem(entry.OriginalPath, filepath.Join(binDir, entry.BinName)); err != nil {
+		return db.Entry{}, err
+	}
+	return entry, nil
+}
+
+// prepare builds the entry for tossing src into binDir and writes its
+// sidecar, without moving anything yet.
+func prepare(src, binDir string) (db.Entry, error) {
 	if err := EnsureDirs(binDir); err != nil {
 		return db.Entry{}, fmt.Errorf("creating bin dir: %w", err)
 	}
+	e, err := newEntry(src, binDir)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	if err := writeSidecar(e); err != nil {
+		return db.Entry{}, err
+	}
+	return e, nil
+}
 
+// newEntry builds the entry for tossing src into binDir.
+func newEntry(src, binDir string) (db.Entry, error) {
 	abs, err := filepath.Abs(src)
 	if err != nil {
 		return db.Entry{}, fmt.Errorf("resolving path: %w", err)
@@ -61,33 +91,34 @@ func Move(src, binDir string) (db.Entry, error) {
 	}
 
 	id := db.NewID()
-	binName := id + "-" + filepath.Base(abs)
-	dest := filepath.Join(binDir, binName)
-
-	if err := moveItem(abs, dest); err != nil {
-		return db.Entry{}, err
-	}
-
-	var size int64
-	if info.IsDir() {
-		size, _ = dirSize(dest)
-	} else {
-		size = info.Size()
-	}
-
-	return db.Entry{
+	e := db.Entry{
 		ID:           id,
 		OriginalPath: abs,
-		BinName:      binName,
+		BinName:      id + "-" + filepath.Base(abs),
+		BinDir:       binDir,
 		TossedAt:     time.Now(),
 		IsDir:        info.IsDir(),
-		SizeBytes:    size,
-	}, nil
+		SizeBytes:    itemSize(abs, info),
+	}
+	setFileMeta(&e, abs, info)
+	setContext(&e)
+	return e, nil
+}
+
+func itemSize(path string, info fs.FileInfo) int64 {
+	if !info.IsDir() {
+		return info.Size()
+	}
+	size, _ := dirSize(path)
+	return size
 }
 
 func Restore(entry db.Entry, binDir string) error {
+	return RestoreTo(entry, binDir, entry.OriginalPath)
+}
+
+func RestoreTo(entry db.Entry, binDir, dest string) error {
 	src := filepath.Join(binDir, entry.BinName)
-	dest := entry.OriginalPath
 
 	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
 		return fmt.Errorf("recreating parent dirs: %w", err)
@@ -96,91 +127,86 @@ func Restore(entry db.Entry, binDir string) error {
 	return moveItem(src, dest)
 }
 
-func Empty(binDir string) error {
-	if err := os.RemoveAll(binDir); err != nil {
-		return fmt.Errorf("removing bin contents: %w", err)
-	}
-	return os.MkdirAll(binDir, 0755)
+// MergeTo restores a tossed directory into an existing directory at dest.
+// Items already at dest are replaced by their tossed versions, except that
+// directories on both sides are merged recursively.
+func MergeTo(entry db.Entry, binDir, dest string) error {
+	return mergeDir(filepath.Join(binDir, entry.BinName), dest)
 }
 
-func moveItem(src, dest string) error {
-	err := os.Rename(src, dest)
-	if err == nil {
-		return nil
+func mergeDir(src, dest string) error {
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
 	}
-	var linkErr *os.LinkError
-	if errors.As(err, &linkErr) && errors.Is(linkErr.Err, syscall.EXDEV) {
-		return copyThenDelete(src, dest)
+	destInfo, err := os.Lstat(dest)
+	if err != nil {
+		return err
+	}
+	if !srcInfo.IsDir() || !destInfo.IsDir() {
+		return fmt.Errorf("cannot merge %s into %s: both must be directories", src, dest)
 	}
-	return err
-}
 
-func copyThenDelete(src, dest string) error {
-	info, err := os.Lstat(src)
+	children, err := os.ReadDir(src)
 	if err != nil {
 		return err
 	}
-	if info.IsDir() {
-		if err := copyDir(src, dest); err != nil {
-			return err
-		}
-	} else if info.Mode()&fs.ModeSymlink != 0 {
-		linkTarget, err := os.Readlink(src)
-		if err != nil {
-			return err
-		}
-		if err := os.Symlink(linkTarget, dest); err != nil {
+	for _, child := range children {
+		from := filepath.Join(src, child.Name())
+		to := filepath.Join(dest, child.Name())
+		existing, err := os.Lstat(to)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
 			return err
+		case child.IsDir() && existing.IsDir():
+			if err := mergeDir(from, to); err != nil {
+				return err
+			}
+			continue
+		default:
+			if err := os.RemoveAll(to); err != nil {
+				return err
+			}
 		}
-	} else {
-		if err := copyFile(src, dest, info.Mode()); err != nil {
+		if err := moveItem(from, to); err != nil {
 			return err
 		}
 	}
-	return os.RemoveAll(src)
+	return os.Remove(src)
 }
 
-func copyDir(src, dest string) error {
-	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+// RestoredName returns the first of path.restored-1, path.restored-2, ...
+// that does not exist yet. It fails if it cannot tell, e.g. when the parent
+// directory cannot be searched.
+func RestoredName(path string) (string, error) {
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s.restored-%d", path, i)
+		_, err := os.Lstat(candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			return candidate, nil
 		}
-		rel, err := filepath.Rel(src, path)
 		if err != nil {
-			return err
-		}
-		target := filepath.Join(dest, rel)
-		if d.Type()&fs.ModeSymlink != 0 {
-			linkTarget, err := os.Readlink(path)
-			if err != nil {
-				return err
-			}
-			return os.Symlink(linkTarget, target)
-		}
-		info, err := d.Info()
-		if err != nil {
-			return err
+			return "", fmt.Errorf("picking a new name: %w", err)
 		}
-		if d.IsDir() {
-			return os.MkdirAll(target, info.Mode())
-		}
-		return copyFile(path, target, info.Mode())
-	})
+	}
 }
 
-func copyFile(src, dest string, mode fs.FileMode) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
+func Empty(binDir string) error {
+	if err := os.RemoveAll(binDir); err != nil {
+		return fmt.Errorf("removing bin contents: %w", err)
 	}
-	defer in.Close()
+	return os.MkdirAll(binDir, 0755)
+}
 
-	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
-	if err != nil {
-		return err
+func moveItem(src, dest string) error {
+	err := os.Rename(src, dest)
+	if err == nil {
+		return nil
+	}
+	var linkErr *os.LinkError
+	if errors.As(err, &linkErr) && errors.Is(linkErr.Err, syscall.EXDEV) {
+		return copyThenDelete(src, dest)
 	}
-	defer out.Close()
-
-	_, err = io.Copy(out, in)
 	return err
 }
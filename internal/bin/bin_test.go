@@ -74,7 +74,7 @@ func TestCopyFile_CopiesContent(t *testing.T) {
 	src := filepath.Join(dir, "src.txt")
 	dst := filepath.Join(dir, "dst.txt")
 	writeFile(t, src, "hello world", 0644)
-	if err := copyFile(src, dst, 0644); err != nil {
+	if err := newCopier().copyFile(src, dst); err != nil {
 		t.Fatalf("copyFile: %v", err)
 	}
 	if got := readFile(t, dst); got != "hello world" {
@@ -88,7 +88,7 @@ func TestCopyFile_PreservesPermissions(t *testing.T) {
 	src := filepath.Join(dir, "src.sh")
 	dst := filepath.Join(dir, "dst.sh")
 	writeFile(t, src, "#!/bin/sh", 0755)
-	if err := copyFile(src, dst, 0755); err != nil {
+	if err := newCopier().copyFile(src, dst); err != nil {
 		t.Fatalf("copyFile: %v", err)
 	}
 	checkPerm(t, dst, 0755)
@@ -102,7 +102,7 @@ func TestCopyDir_CopiesFiles(t *testing.T) {
 	dst := filepath.Join(dir, "dst")
 	writeFile(t, filepath.Join(src, "a.txt"), "aaa", 0644)
 	writeFile(t, filepath.Join(src, "b.txt"), "bbb", 0644)
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir: %v", err)
 	}
 	if got := readFile(t, filepath.Join(dst, "a.txt")); got != "aaa" {
@@ -118,7 +118,7 @@ func TestCopyDir_NestedStructure(t *testing.T) {
 	src := filepath.Join(dir, "src")
 	dst := filepath.Join(dir, "dst")
 	writeFile(t, filepath.Join(src, "sub", "file.txt"), "nested", 0644)
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir: %v", err)
 	}
 	if got := readFile(t, filepath.Join(dst, "sub", "file.txt")); got != "nested" {
@@ -133,7 +133,7 @@ func TestCopyDir_PreservesFilePermissions(t *testing.T) {
 	dst := filepath.Join(dir, "dst")
 	writeFile(t, filepath.Join(src, "script.sh"), "#!/bin/sh", 0755)
 	writeFile(t, filepath.Join(src, "data.bin"), "secret", 0600)
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir: %v", err)
 	}
 	checkPerm(t, filepath.Join(dst, "script.sh"), 0755)
@@ -156,7 +156,7 @@ func TestCopyDir_PreservesDirPermissions(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(subdir, "f.txt"), []byte("x"), 0644); err != nil {
 		t.Fatalf("WriteFile: %v", err)
 	}
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir: %v", err)
 	}
 	checkPerm(t, dst, 0700)
@@ -174,7 +174,7 @@ func TestCopyDir_RecreatesSymlinks(t *testing.T) {
 	if err := os.Symlink("real.txt", filepath.Join(src, "link.txt")); err != nil {
 		t.Fatalf("Symlink: %v", err)
 	}
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir: %v", err)
 	}
 	checkSymlink(t, filepath.Join(dst, "link.txt"), "real.txt")
@@ -190,7 +190,7 @@ func TestCopyDir_DanglingSymlink(t *testing.T) {
 	if err := os.Symlink("/nonexistent/path", filepath.Join(src, "dangling.txt")); err != nil {
 		t.Fatalf("Symlink: %v", err)
 	}
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir with dangling symlink: %v", err)
 	}
 	checkSymlink(t, filepath.Join(dst, "dangling.txt"), "/nonexistent/path")
@@ -207,7 +207,7 @@ func TestCopyDir_AbsoluteSymlink(t *testing.T) {
 	if err := os.Symlink(absTarget, filepath.Join(src, "env")); err != nil {
 		t.Fatalf("Symlink: %v", err)
 	}
-	if err := copyDir(src, dst); err != nil {
+	if err := newCopier().copyDir(src, dst); err != nil {
 		t.Fatalf("copyDir: %v", err)
 	}
 	checkSymlink(t, filepath.Join(dst, "env"), absTarget)
@@ -462,6 +462,96 @@ func TestRestore_RoundTrip_PreservesContent(t *testing.T) {
 	}
 }
 
+func TestRestoreTo_AlternateDestination(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "notes.txt")
+	binDir := filepath.Join(dir, "bin")
+	writeFile(t, src, "data", 0644)
+	entry, err := Move(src, binDir)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	dest := filepath.Join(dir, "elsewhere", "copy.txt")
+	if err := RestoreTo(entry, binDir, dest); err != nil {
+		t.Fatalf("RestoreTo: %v", err)
+	}
+	if got := readFile(t, dest); got != "data" {
+		t.Errorf("restored content: want 'data', got %q", got)
+	}
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Error("original path should stay empty")
+	}
+}
+
+func TestMergeTo_MergesDirectories(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "project")
+	binDir := filepath.Join(dir, "bin")
+	writeFile(t, filepath.Join(src, "a.txt"), "tossed a", 0644)
+	writeFile(t, filepath.Join(src, "sub", "b.txt"), "tossed b", 0644)
+	entry, err := Move(src, binDir)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	writeFile(t, filepath.Join(src, "a.txt"), "new a", 0644)
+	writeFile(t, filepath.Join(src, "sub", "c.txt"), "new c", 0644)
+
+	if err := MergeTo(entry, binDir, src); err != nil {
+		t.Fatalf("MergeTo: %v", err)
+	}
+	if got := readFile(t, filepath.Join(src, "a.txt")); got != "tossed a" {
+		t.Errorf("a.txt: want tossed version, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(src, "sub", "b.txt")); got != "tossed b" {
+		t.Errorf("sub/b.txt: got %q", got)
+	}
+	if got := readFile(t, filepath.Join(src, "sub", "c.txt")); got != "new c" {
+		t.Errorf("sub/c.txt should be kept, got %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(binDir, entry.BinName)); !os.IsNotExist(err) {
+		t.Error("directory should be gone from bin after MergeTo")
+	}
+}
+
+func TestMergeTo_RejectsFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "file.txt")
+	binDir := filepath.Join(dir, "bin")
+	writeFile(t, src, "old", 0644)
+	entry, err := Move(src, binDir)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	writeFile(t, src, "new", 0644)
+	if err := MergeTo(entry, binDir, src); err == nil {
+		t.Error("expected error merging a file")
+	}
+}
+
+func TestRestoredName(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	writeFile(t, path, "x", 0644)
+	if got, err := RestoredName(path); err != nil || got != path+".restored-1" {
+		t.Errorf("want %s.restored-1, got %s (%v)", path, got, err)
+	}
+	writeFile(t, path+".restored-1", "x", 0644)
+	if got, err := RestoredName(path); err != nil || got != path+".restored-2" {
+		t.Errorf("want %s.restored-2, got %s (%v)", path, got, err)
+	}
+}
+
+func TestRestoredName_StopsOnError(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	writeFile(t, file, "x", 0644)
+	// Below a file, every candidate fails with ENOTDIR rather than not
+	// existing, as it would below a directory that cannot be searched.
+	if got, err := RestoredName(filepath.Join(file, "report.txt")); err == nil {
+		t.Errorf("want an error, got %s", got)
+	}
+}
+
 // Empty tests
 
 func TestEmpty_RemovesFiles(t *testing.T) {
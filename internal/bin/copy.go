@@ -0,0 +1,334 @@
+package bin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Warn is called after a cross-filesystem move, or a copy out of the bin,
+// that could not carry over some of the item's metadata, e.g. ownership
+// when not running as root. The data itself was moved.
+var Warn = func(path string, lost []string) {}
+
+// copyThenDelete copies src to dest with copyInto and then removes src.
+func copyThenDelete(src, dest string) error {
+	if err := copyInto(src, dest); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyInto copies src under a temporary name next to dest and renames it
+// into place once complete, so dest never holds a half-copied tree.
+func copyInto(src, dest string) error {
+	partial := partialPath(dest)
+	c := newCopier()
+	if err := c.copy(src, partial); err != nil {
+		os.RemoveAll(partial)
+		return err
+	}
+	if err := os.Rename(partial, dest); err != nil {
+		os.RemoveAll(partial)
+		return err
+	}
+	if lost := c.lostMetadata(); len(lost) > 0 {
+		Warn(src, lost)
+	}
+	return nil
+}
+
+// partialPath is where copyInto builds the copy of dest.
+func partialPath(dest string) string {
+	return filepath.Join(filepath.Dir(dest), ".toss-partial."+filepath.Base(dest))
+}
+
+// copier copies files between filesystems, keeping ownership, timestamps,
+// extended attributes (and with them ACLs), hard links and holes where the
+// destination allows, and recording what it could not keep.
+type copier struct {
+	links map[fileID]*linkedFile
+	lost  map[string]bool
+}
+
+type fileID struct{ dev, ino uint64 }
+
+type linkedFile struct {
+	path  string
+	nlink uint64
+	seen  uint64
+}
+
+func newCopier() *copier {
+	return &copier{links: map[fileID]*linkedFile{}, lost: map[string]bool{}}
+}
+
+func (c *copier) lostMetadata() []string {
+	for _, l := range c.links {
+		if l.seen < l.nlink {
+			c.lost["hard links"] = true
+		}
+	}
+	var lost []string
+	for what := range c.lost {
+		lost = append(lost, what)
+	}
+	slices.Sort(lost)
+	return lost
+}
+
+func (c *copier) copy(src, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		return c.copyDir(src, dest)
+	case info.Mode()&fs.ModeSymlink != 0:
+		return c.copySymlink(src, dest)
+	default:
+		return c.copyFile(src, dest)
+	}
+}
+
+func (c *copier) copyDir(src, dest string) error {
+	// Directory metadata is applied once their contents are in place, deepest
+	// first, so that writing children does not reset mtimes or trip over
+	// read-only modes.
+	var dirs []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, rel)
+			return os.MkdirAll(target, 0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			return c.copySymlink(path, target)
+		default:
+			return c.copyFile(path, target)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, rel := range slices.Backward(dirs) {
+		var st unix.Stat_t
+		if err := unix.Lstat(filepath.Join(src, rel), &st); err != nil {
+			return err
+		}
+		if err := c.preserve(filepath.Join(src, rel), filepath.Join(dest, rel), &st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copier) copySymlink(src, dest string) error {
+	linkTarget, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(linkTarget, dest); err != nil {
+		return err
+	}
+	var st unix.Stat_t
+	if err := unix.Lstat(src, &st); err != nil {
+		return err
+	}
+	return c.preserve(src, dest, &st)
+}
+
+func (c *copier) copyFile(src, dest string) error {
+	var st unix.Stat_t
+	if err := unix.Lstat(src, &st); err != nil {
+		return err
+	}
+	if st.Nlink > 1 {
+		id := fileID{uint64(st.Dev), uint64(st.Ino)}
+		if l, ok := c.links[id]; ok {
+			l.seen++
+			return os.Link(l.path, dest)
+		}
+		c.links[id] = &linkedFile{path: dest, nlink: uint64(st.Nlink), seen: 1}
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// Fewer allocated blocks than the size implies there are holes.
+	if st.Blocks*512 < st.Size {
+		err = copySparse(out, in, st.Size)
+	} else {
+		_, err = io.Copy(out, in)
+	}
+	if err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return c.preserve(src, dest, &st)
+}
+
+// copySparse copies only the data regions of in, leaving holes in out.
+func copySparse(out, in *os.File, size int64) error {
+	fd := int(in.Fd())
+	for off := int64(0); off < size; {
+		data, err := unix.Seek(fd, off, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break // only a hole remains
+		}
+		if err != nil {
+			// No SEEK_DATA support: copy the rest densely.
+			if _, err := in.Seek(off, io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := out.Seek(off, io.SeekStart); err != nil {
+				return err
+			}
+			_, err = io.Copy(out, in)
+			return err
+		}
+		hole, err := unix.Seek(fd, data, unix.SEEK_HOLE)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Seek(data, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, io.NewSectionReader(in, data, hole-data)); err != nil {
+			return err
+		}
+		off = hole
+	}
+	return out.Truncate(size)
+}
+
+// preserve copies ownership, mode, extended attributes and timestamps from
+// src to dest. Failures the destination cannot help (no permission, no
+// xattr support) are recorded as lost; anything else is an error.
+func (c *copier) preserve(src, dest string, st *unix.Stat_t) error {
+	isLink := st.Mode&unix.S_IFMT == unix.S_IFLNK
+
+	if err := unix.Lchown(dest, int(st.Uid), int(st.Gid)); err != nil {
+		if !unsupported(err) {
+			return fmt.Errorf("preserving ownership of %s: %w", dest, err)
+		}
+		c.lost["ownership"] = true
+	}
+
+	// Set the mode after chown, which clears setuid and setgid bits.
+	if !isLink {
+		info, err := os.Lstat(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(dest, info.Mode()); err != nil {
+			return fmt.Errorf("preserving mode of %s: %w", dest, err)
+		}
+	}
+
+	if err := c.copyXattrs(src, dest); err != nil {
+		return err
+	}
+
+	times := []unix.Timespec{st.Atim, st.Mtim}
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, dest, times, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		if !unsupported(err) {
+			return fmt.Errorf("preserving timestamps of %s: %w", dest, err)
+		}
+		c.lost["timestamps"] = true
+	}
+	return nil
+}
+
+func (c *copier) copyXattrs(src, dest string) error {
+	names, err := listXattrs(src)
+	if unsupported(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("listing extended attributes of %s: %w", src, err)
+	}
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err != nil {
+			return fmt.Errorf("reading extended attribute %s of %s: %w", name, src, err)
+		}
+		if err := unix.Lsetxattr(dest, name, value, 0); err != nil {
+			if !unsupported(err) {
+				return fmt.Errorf("setting extended attribute %s on %s: %w", name, dest, err)
+			}
+			if strings.HasPrefix(name, "system.posix_acl_") {
+				c.lost["ACLs"] = true
+			} else {
+				c.lost["extended attributes"] = true
+			}
+		}
+	}
+	return nil
+}
+
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(buf[:n]), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	size, err := unix.Lgetxattr(path, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := unix.Lgetxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// unsupported reports whether err means the destination cannot hold a piece
+// of metadata, as opposed to a real I/O failure.
+func unsupported(err error) bool {
+	return errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) ||
+		errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) ||
+		errors.Is(err, unix.EINVAL)
+}
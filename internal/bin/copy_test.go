@@ -0,0 +1,186 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopier_PreservesTimestamps(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	writeFile(t, filepath.Join(src, "sub", "old.txt"), "x", 0644)
+	mtime := time.Date(2020, 5, 17, 8, 30, 0, 0, time.UTC)
+	for _, p := range []string{filepath.Join(src, "sub", "old.txt"), filepath.Join(src, "sub"), src} {
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+	if err := newCopier().copyDir(src, dst); err != nil {
+		t.Fatalf("copyDir: %v", err)
+	}
+	for _, rel := range []string{"sub/old.txt", "sub", "."} {
+		info, err := os.Lstat(filepath.Join(dst, rel))
+		if err != nil {
+			t.Fatalf("Lstat: %v", err)
+		}
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: mtime want %v, got %v", rel, mtime, info.ModTime())
+		}
+	}
+}
+
+func TestCopier_PreservesReadOnlyDir(t *testing.T) {
+	zeroUmask(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	writeFile(t, filepath.Join(src, "locked", "f.txt"), "x", 0644)
+	if err := os.Chmod(filepath.Join(src, "locked"), 0555); err != nil {
+		t.Fatalf("Chmod: %v", err)
+	}
+	t.Cleanup(func() {
+		os.Chmod(filepath.Join(src, "locked"), 0755)
+		os.Chmod(filepath.Join(dst, "locked"), 0755)
+	})
+	if err := newCopier().copyDir(src, dst); err != nil {
+		t.Fatalf("copyDir: %v", err)
+	}
+	checkPerm(t, filepath.Join(dst, "locked"), 0555)
+	if got := readFile(t, filepath.Join(dst, "locked", "f.txt")); got != "x" {
+		t.Errorf("content: got %q", got)
+	}
+}
+
+func TestCopier_PreservesHardLinks(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	writeFile(t, filepath.Join(src, "a.txt"), "shared", 0644)
+	if err := os.Link(filepath.Join(src, "a.txt"), filepath.Join(src, "b.txt")); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	c := newCopier()
+	if err := c.copyDir(src, dst); err != nil {
+		t.Fatalf("copyDir: %v", err)
+	}
+	a, err := os.Stat(filepath.Join(dst, "a.txt"))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	b, err := os.Stat(filepath.Join(dst, "b.txt"))
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if !os.SameFile(a, b) {
+		t.Error("a.txt and b.txt should still be the same file")
+	}
+	if lost := c.lostMetadata(); slices.Contains(lost, "hard links") {
+		t.Errorf("no hard links should be lost, got %v", lost)
+	}
+}
+
+func TestCopier_ReportsHardLinksOutsideTree(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "a.txt"), "shared", 0644)
+	if err := os.Link(filepath.Join(src, "a.txt"), filepath.Join(dir, "outside.txt")); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	c := newCopier()
+	if err := c.copyDir(src, filepath.Join(dir, "dst")); err != nil {
+		t.Fatalf("copyDir: %v", err)
+	}
+	if lost := c.lostMetadata(); !slices.Contains(lost, "hard links") {
+		t.Errorf("want hard links reported lost, got %v", lost)
+	}
+}
+
+func TestCopier_KeepsHoles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "sparse.img")
+	dst := filepath.Join(dir, "copy.img")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	const size = 8 << 20
+	if _, err := f.WriteAt([]byte("head"), 0); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	if _, err := f.WriteAt([]byte("tail"), size-4); err != nil {
+		t.Fatalf("WriteAt: %v", err)
+	}
+	f.Close()
+	if allocated(t, src) >= size {
+		t.Skip("filesystem does not support sparse files")
+	}
+
+	if err := newCopier().copyFile(src, dst); err != nil {
+		t.Fatalf("copyFile: %v", err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if len(data) != size || string(data[:4]) != "head" || string(data[size-4:]) != "tail" {
+		t.Fatalf("sparse content not preserved (len %d)", len(data))
+	}
+	if got := allocated(t, dst); got >= size {
+		t.Errorf("copy should stay sparse, %d bytes allocated", got)
+	}
+}
+
+func TestCopier_CopiesXattrs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	writeFile(t, src, "x", 0644)
+	if err := unix.Lsetxattr(src, "user.toss.test", []byte("kept"), 0); err != nil {
+		t.Skipf("filesystem does not support user xattrs: %v", err)
+	}
+	if err := newCopier().copyFile(src, dst); err != nil {
+		t.Fatalf("copyFile: %v", err)
+	}
+	got, err := getXattr(dst, "user.toss.test")
+	if err != nil {
+		t.Fatalf("getXattr: %v", err)
+	}
+	if string(got) != "kept" {
+		t.Errorf("xattr: want 'kept', got %q", got)
+	}
+}
+
+func TestCopyThenDelete_WarnsAboutLostMetadata(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "x", 0644)
+	if err := os.Link(src, filepath.Join(dir, "other.txt")); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	var warned []string
+	old := Warn
+	Warn = func(path string, lost []string) { warned = lost }
+	t.Cleanup(func() { Warn = old })
+
+	if err := copyThenDelete(src, filepath.Join(dir, "b.txt")); err != nil {
+		t.Fatalf("copyThenDelete: %v", err)
+	}
+	if !slices.Contains(warned, "hard links") {
+		t.Errorf("want warning about hard links, got %v", warned)
+	}
+}
+
+func allocated(t *testing.T, path string) int64 {
+	t.Helper()
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	return st.Blocks * 512
+}
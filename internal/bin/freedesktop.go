@@ -0,0 +1,453 @@
+package bin
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// trashInfoTime is the DeletionDate format from the FreeDesktop.org Trash
+// specification: local time, no zone.
+const trashInfoTime = "2006-01-02T15:04:05"
+
+// TrashDir returns the home trash, $XDG_DATA_HOME/Trash.
+func TrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding home dir: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// freedesktopBackend stores items in FreeDesktop.org trash directories so
+// they are shared with desktop file managers: the home trash, or
+// $topdir/.Trash/$uid or $topdir/.Trash-$uid for items on other filesystems.
+// Each item in files/ has a matching info/<name>.trashinfo; the database is
+// only an index over them.
+type freedesktopBackend struct {
+	trashDir string
+	dbPath   string
+}
+
+func (b *freedesktopBackend) Root() string   { return b.trashDir }
+func (b *freedesktopBackend) Dir() string    { return b.home().files() }
+func (b *freedesktopBackend) DBPath() string { return b.dbPath }
+
+func (b *freedesktopBackend) home() trash {
+	return trash{dir: b.trashDir}
+}
+
+// trashAt returns the trash whose files/ directory is filesDir.
+func (b *freedesktopBackend) trashAt(filesDir string) trash {
+	dir := filepath.Dir(filesDir)
+	switch {
+	case dir == b.trashDir:
+		return b.home()
+	case filepath.Base(filepath.Dir(dir)) == ".Trash":
+		return trash{dir: dir, topdir: filepath.Dir(filepath.Dir(dir))}
+	default:
+		return trash{dir: dir, topdir: filepath.Dir(dir)}
+	}
+}
+
+// trashFor picks the trash on the same filesystem as abs, falling back to
+// the home trash when none can be used. Unless create is set nothing is
+// created, and the trash is the one that would be.
+func (b *freedesktopBackend) trashFor(abs string, create bool) trash {
+	own := mayOwn
+	if create {
+		if err := b.home().ensureDirs(); err != nil {
+			return b.home()
+		}
+		own = ownedDir
+	}
+	topdir, ok := foreignTopdir(abs, b.trashDir)
+	if !ok {
+		return b.home()
+	}
+	uid := strconv.Itoa(os.Getuid())
+	shared := filepath.Join(topdir, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&fs.ModeSticky != 0 {
+		if dir := filepath.Join(shared, uid); own(dir) {
+			return trash{dir: dir, topdir: topdir}
+		}
+	}
+	if dir := filepath.Join(topdir, ".Trash-"+uid); own(dir) {
+		return trash{dir: dir, topdir: topdir}
+	}
+	return b.home()
+}
+
+// Bins returns the home trash, the trashes the index knows about and any
+// topdir trash of ours on a mounted filesystem.
+func (b *freedesktopBackend) Bins(d *sql.DB) ([]string, error) {
+	bins, err := knownBins(d, b.Dir())
+	if err != nil {
+		return nil, err
+	}
+	uid := strconv.Itoa(os.Getuid())
+	return withMountBins(bins, func(point string) []string {
+		return []string{
+			filepath.Join(point, ".Trash", uid, "files"),
+			filepath.Join(point, ".Trash-"+uid, "files"),
+		}
+	})
+}
+
+func (b *freedesktopBackend) Path(e db.Entry) string {
+	return filepath.Join(entryDir(e, b.Dir()), e.BinName)
+}
+
+func (b *freedesktopBackend) Prepare(src string) (db.Entry, error) {
+	return b.prepare(src, true)
+}
+
+func (b *freedesktopBackend) Preview(src string) (db.Entry, error) {
+	return b.prepare(src, false)
+}
+
+// prepare builds the entry for src, reserving its name in the trash if
+// reserve is set. Otherwise nothing is created.
+func (b *freedesktopBackend) prepare(src string, reserve bool) (db.Entry, error) {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return db.Entry{}, fmt.Errorf("resolving path: %w", err)
+	}
+
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return db.Entry{}, fmt.Errorf("%s: %w", src, err)
+	}
+
+	t := b.trashFor(abs, reserve)
+	now := time.Now().Truncate(time.Second)
+	name := ""
+	if reserve {
+		if err := t.ensureDirs(); err != nil {
+			return db.Entry{}, fmt.Errorf("creating trash dir: %w", err)
+		}
+		if name, err = t.reserve(abs, now); err != nil {
+			return db.Entry{}, fmt.Errorf("writing trash info: %w", err)
+		}
+	} else {
+		name = t.freeName(abs)
+	}
+
+	e := db.Entry{
+		ID:           db.NewID(),
+		OriginalPath: abs,
+		BinName:      name,
+		BinDir:       t.files(),
+		TossedAt:     now,
+		IsDir:        info.IsDir(),
+		SizeBytes:    itemSize(abs, info),
+	}
+	setFileMeta(&e, abs, info)
+	setContext(&e)
+	return e, nil
+}
+
+func (b *freedesktopBackend) Restore(e db.Entry, dest string) error {
+	dir := entryDir(e, b.Dir())
+	if err := RestoreTo(e, dir, dest); err != nil {
+		return err
+	}
+	return b.trashAt(dir).removeInfo(e.BinName)
+}
+
+func (b *freedesktopBackend) Merge(e db.Entry, dest string) error {
+	dir := entryDir(e, b.Dir())
+	if err := MergeTo(e, dir, dest); err != nil {
+		return err
+	}
+	return b.trashAt(dir).removeInfo(e.BinName)
+}
+
+func (b *freedesktopBackend) Delete(e db.Entry) error {
+	if err := os.RemoveAll(b.Path(e)); err != nil {
+		return err
+	}
+	return b.trashAt(entryDir(e, b.Dir())).removeInfo(e.BinName)
+}
+
+func (b *freedesktopBackend) Empty(binDir string) error {
+	return b.trashAt(binDir).empty()
+}
+
+// Sync drops index rows for items that left the trash (restored or deleted
+// by another program) and adds rows for items trashed by other programs.
+func (b *freedesktopBackend) Sync(d *sql.DB) error {
+	entries, badTime, err := db.AllChecked(d)
+	if err != nil {
+		return err
+	}
+
+	// Items another process is tossing right now are about to be indexed.
+	known, err := inFlight(b, d)
+	if err != nil {
+		return err
+	}
+	for _, e := range append(entries, badTime...) {
+		path := b.Path(e)
+		if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+			if err := db.Remove(d, e.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		known[path] = true
+	}
+
+	bins, err := b.Bins(d)
+	if err != nil {
+		return err
+	}
+	for _, binDir := range bins {
+		t := b.trashAt(binDir)
+		infos, err := os.ReadDir(filepath.Join(t.dir, "info"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, de := range infos {
+			name, ok := strings.CutSuffix(de.Name(), ".trashinfo")
+			if !ok || known[filepath.Join(binDir, name)] {
+				continue
+			}
+			e, err := t.readEntry(name)
+			if err != nil {
+				// Malformed or orphaned info files belong to someone else; leave them.
+				continue
+			}
+			if err := db.Append(d, e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Adopt writes the missing .trashinfo for an item found in binDir. The
+// original directory is unknown, so the item is placed at the top of the
+// trash's filesystem, or in the home directory for the home trash.
+func (b *freedesktopBackend) Adopt(binDir, name string) (db.Entry, error) {
+	t := b.trashAt(binDir)
+	path := filepath.Join(binDir, name)
+	tossedAt, err := changeTime(path)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	orig := name
+	if t.topdir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return db.Entry{}, fmt.Errorf("finding home dir: %w", err)
+		}
+		orig = filepath.Join(home, name)
+	}
+	info := formatTrashInfo(orig, tossedAt.Truncate(time.Second))
+	if err := os.WriteFile(t.infoPath(name), []byte(info), 0600); err != nil {
+		return db.Entry{}, fmt.Errorf("writing trash info: %w", err)
+	}
+	return t.readEntry(name)
+}
+
+// trash is a single trash directory. For trashes on other filesystems
+// topdir is set and Path= keys are relative to it.
+type trash struct {
+	dir    string
+	topdir string
+}
+
+func (t trash) files() string {
+	return filepath.Join(t.dir, "files")
+}
+
+func (t trash) infoPath(name string) string {
+	return filepath.Join(t.dir, "info", name+".trashinfo")
+}
+
+func (t trash) ensureDirs() error {
+	for _, dir := range []string{t.files(), filepath.Join(t.dir, "info")} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserve picks a free name in the trash and claims it by exclusively
+// creating its .trashinfo file, as the specification requires.
+func (t trash) reserve(abs string, deleted time.Time) (string, error) {
+	recorded := abs
+	if t.topdir != "" {
+		if rel, err := filepath.Rel(t.topdir, abs); err == nil {
+			recorded = rel
+		}
+	}
+
+	for i := 1; ; i++ {
+		name := candidateName(filepath.Base(abs), i)
+		if _, err := os.Lstat(filepath.Join(t.files(), name)); err == nil {
+			continue
+		}
+		f, err := os.OpenFile(t.infoPath(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = io.WriteString(f, formatTrashInfo(recorded, deleted))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(t.infoPath(name))
+			return "", err
+		}
+		return name, nil
+	}
+}
+
+// freeName returns the name reserve would pick for abs, without claiming
+// it.
+func (t trash) freeName(abs string) string {
+	for i := 1; ; i++ {
+		name := candidateName(filepath.Base(abs), i)
+		_, ferr := os.Lstat(filepath.Join(t.files(), name))
+		_, ierr := os.Lstat(t.infoPath(name))
+		if ferr != nil && ierr != nil {
+			return name
+		}
+	}
+}
+
+// candidateName is the i-th name tried for base in a trash: base itself,
+// then "stem.2.ext", "stem.3.ext" and so on.
+func candidateName(base string, i int) string {
+	if i == 1 {
+		return base
+	}
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if stem == "" {
+		stem, ext = base, ""
+	}
+	return fmt.Sprintf("%s.%d%s", stem, i, ext)
+}
+
+func (t trash) readEntry(name string) (db.Entry, error) {
+	f, err := os.Open(t.infoPath(name))
+	if err != nil {
+		return db.Entry{}, err
+	}
+	defer f.Close()
+
+	origPath, deleted, err := parseTrashInfo(f)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	if !filepath.IsAbs(origPath) {
+		if t.topdir == "" {
+			return db.Entry{}, fmt.Errorf("relative Path in home trash")
+		}
+		origPath = filepath.Join(t.topdir, origPath)
+	}
+
+	item := filepath.Join(t.files(), name)
+	info, err := os.Lstat(item)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	e := db.Entry{
+		ID:           db.NewID(),
+		OriginalPath: origPath,
+		BinName:      name,
+		BinDir:       t.files(),
+		TossedAt:     deleted,
+		IsDir:        info.IsDir(),
+		SizeBytes:    itemSize(item, info),
+	}
+	setFileMeta(&e, item, info)
+	return e, nil
+}
+
+func (t trash) removeInfo(name string) error {
+	if err := os.Remove(t.infoPath(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing trash info: %w", err)
+	}
+	return nil
+}
+
+func (t trash) empty() error {
+	for _, name := range []string{"files", "info", "directorysizes"} {
+		if err := os.RemoveAll(filepath.Join(t.dir, name)); err != nil {
+			return fmt.Errorf("removing trash contents: %w", err)
+		}
+	}
+	return t.ensureDirs()
+}
+
+func formatTrashInfo(path string, deleted time.Time) string {
+	escaped := (&url.URL{Path: path}).EscapedPath()
+	return fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, deleted.Format(trashInfoTime))
+}
+
+func parseTrashInfo(r io.Reader) (path string, deleted time.Time, err error) {
+	scanner := bufio.NewScanner(r)
+	inGroup := false
+	var rawPath, rawDate string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inGroup = line == "[Trash Info]"
+			continue
+		}
+		if !inGroup {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		switch key {
+		case "Path":
+			rawPath = value
+		case "DeletionDate":
+			rawDate = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", time.Time{}, err
+	}
+	if rawPath == "" {
+		return "", time.Time{}, fmt.Errorf("missing Path")
+	}
+	path, err = url.PathUnescape(rawPath)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("decoding Path: %w", err)
+	}
+	deleted, err = time.ParseInLocation(trashInfoTime, rawDate, time.Local)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("parsing DeletionDate: %w", err)
+	}
+	return path, deleted, nil
+}
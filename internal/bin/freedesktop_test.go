@@ -0,0 +1,243 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func newTestTrash(t *testing.T) (*freedesktopBackend, string) {
+	t.Helper()
+	dir := t.TempDir()
+	b := &freedesktopBackend{
+		trashDir: filepath.Join(dir, "Trash"),
+		dbPath:   filepath.Join(dir, "trash.db"),
+	}
+	return b, dir
+}
+
+func TestFreedesktop_MoveWritesTrashInfo(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src := filepath.Join(dir, "my notes.txt")
+	writeFile(t, src, "content", 0644)
+	entry, err := moveInto(b, src)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if entry.BinName != "my notes.txt" {
+		t.Errorf("BinName: want %q, got %q", "my notes.txt", entry.BinName)
+	}
+	if got := readFile(t, b.Path(entry)); got != "content" {
+		t.Errorf("trashed content: want 'content', got %q", got)
+	}
+	info := readFile(t, b.home().infoPath(entry.BinName))
+	if !strings.HasPrefix(info, "[Trash Info]\n") {
+		t.Errorf("trashinfo missing header:\n%s", info)
+	}
+	if !strings.Contains(info, "Path="+filepath.ToSlash(dir)+"/my%20notes.txt\n") {
+		t.Errorf("trashinfo Path not escaped as expected:\n%s", info)
+	}
+	if !strings.Contains(info, "DeletionDate="+entry.TossedAt.Format(trashInfoTime)) {
+		t.Errorf("trashinfo DeletionDate mismatch:\n%s", info)
+	}
+}
+
+func TestFreedesktop_MoveCollisionGetsNewName(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src1 := filepath.Join(dir, "a", "report.txt")
+	src2 := filepath.Join(dir, "b", "report.txt")
+	writeFile(t, src1, "one", 0644)
+	writeFile(t, src2, "two", 0644)
+	e1, err := moveInto(b, src1)
+	if err != nil {
+		t.Fatalf("Move 1: %v", err)
+	}
+	e2, err := moveInto(b, src2)
+	if err != nil {
+		t.Fatalf("Move 2: %v", err)
+	}
+	if e1.BinName != "report.txt" || e2.BinName != "report.2.txt" {
+		t.Errorf("want report.txt and report.2.txt, got %q and %q", e1.BinName, e2.BinName)
+	}
+}
+
+func TestFreedesktop_PreviewPicksFreeNameWithoutReserving(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src1 := filepath.Join(dir, "a", "report.txt")
+	src2 := filepath.Join(dir, "b", "report.txt")
+	writeFile(t, src1, "one", 0644)
+	writeFile(t, src2, "two", 0644)
+	if _, err := moveInto(b, src1); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	for range 2 {
+		e, err := b.Preview(src2)
+		if err != nil {
+			t.Fatalf("Preview: %v", err)
+		}
+		if e.BinName != "report.2.txt" {
+			t.Errorf("BinName: want report.2.txt, got %q", e.BinName)
+		}
+	}
+	if _, err := os.Lstat(b.home().infoPath("report.2.txt")); !os.IsNotExist(err) {
+		t.Errorf("Preview reserved its name: %v", err)
+	}
+}
+
+func TestFreedesktop_RestoreRemovesTrashInfo(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src := filepath.Join(dir, "file.txt")
+	writeFile(t, src, "data", 0644)
+	entry, err := moveInto(b, src)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if err := b.Restore(entry, entry.OriginalPath); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got := readFile(t, src); got != "data" {
+		t.Errorf("restored content: want 'data', got %q", got)
+	}
+	if _, err := os.Lstat(b.home().infoPath(entry.BinName)); !os.IsNotExist(err) {
+		t.Error("trashinfo should be removed after Restore")
+	}
+}
+
+func TestFreedesktop_SyncImportsForeignItems(t *testing.T) {
+	b, _ := newTestTrash(t)
+	if err := b.home().ensureDirs(); err != nil {
+		t.Fatalf("ensureDirs: %v", err)
+	}
+	writeFile(t, filepath.Join(b.Dir(), "photo.jpg"), "jpeg", 0644)
+	writeFile(t, b.home().infoPath("photo.jpg"),
+		"[Trash Info]\nPath=/home/user/Pictures/photo.jpg\nDeletionDate=2026-03-01T12:30:00\n", 0600)
+
+	d, err := db.Open(b.dbPath)
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+	if err := b.Sync(d); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	entries, err := db.All(d)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("want 1 entry after Sync, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.OriginalPath != "/home/user/Pictures/photo.jpg" {
+		t.Errorf("OriginalPath: got %q", e.OriginalPath)
+	}
+	want := time.Date(2026, 3, 1, 12, 30, 0, 0, time.Local)
+	if !e.TossedAt.Equal(want) {
+		t.Errorf("TossedAt: want %v, got %v", want, e.TossedAt)
+	}
+	if e.SizeBytes != 4 {
+		t.Errorf("SizeBytes: want 4, got %d", e.SizeBytes)
+	}
+
+	// A second sync must not duplicate the row.
+	if err := b.Sync(d); err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if entries, _ := db.All(d); len(entries) != 1 {
+		t.Errorf("want 1 entry after second Sync, got %d", len(entries))
+	}
+}
+
+func TestFreedesktop_SyncDropsVanishedItems(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src := filepath.Join(dir, "gone.txt")
+	writeFile(t, src, "x", 0644)
+	entry, err := moveInto(b, src)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	d, err := db.Open(b.dbPath)
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+	if err := db.Append(d, entry); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// Simulate a file manager emptying the trash.
+	if err := os.RemoveAll(b.trashDir); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if err := b.Sync(d); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if entries, _ := db.All(d); len(entries) != 0 {
+		t.Errorf("want 0 entries after Sync, got %d", len(entries))
+	}
+}
+
+func TestFreedesktop_EmptyClearsFilesAndInfo(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src := filepath.Join(dir, "file.txt")
+	writeFile(t, src, "x", 0644)
+	if _, err := moveInto(b, src); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if err := b.Empty(b.Dir()); err != nil {
+		t.Fatalf("Empty: %v", err)
+	}
+	for _, sub := range []string{"files", "info"} {
+		entries, err := os.ReadDir(filepath.Join(b.trashDir, sub))
+		if err != nil {
+			t.Fatalf("ReadDir %s: %v", sub, err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("%s should be empty, got %d entries", sub, len(entries))
+		}
+	}
+}
+
+func TestParseTrashInfo_DecodesPath(t *testing.T) {
+	input := "[Trash Info]\nPath=/tmp/a%20b/%C3%A9.txt\nDeletionDate=2026-01-02T03:04:05\n"
+	path, deleted, err := parseTrashInfo(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseTrashInfo: %v", err)
+	}
+	if path != "/tmp/a b/é.txt" {
+		t.Errorf("path: got %q", path)
+	}
+	if deleted.Format(trashInfoTime) != "2026-01-02T03:04:05" {
+		t.Errorf("deleted: got %v", deleted)
+	}
+}
+
+func TestParseTrashInfo_MissingHeader(t *testing.T) {
+	input := "Path=/tmp/x\nDeletionDate=2026-01-02T03:04:05\n"
+	if _, _, err := parseTrashInfo(strings.NewReader(input)); err == nil {
+		t.Error("expected error when [Trash Info] group is missing")
+	}
+}
+
+func TestFreedesktop_DeleteRemovesItemAndInfo(t *testing.T) {
+	b, dir := newTestTrash(t)
+	src := filepath.Join(dir, "old.log")
+	writeFile(t, src, "x", 0644)
+	entry, err := moveInto(b, src)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if err := b.Delete(entry); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Lstat(b.Path(entry)); !os.IsNotExist(err) {
+		t.Error("item should be gone after Delete")
+	}
+	if _, err := os.Lstat(b.home().infoPath(entry.BinName)); !os.IsNotExist(err) {
+		t.Error("trashinfo should be gone after Delete")
+	}
+}
@@ -0,0 +1,190 @@
+package bin
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+	"golang.org/x/sys/unix"
+)
+
+// Kinds of Problem found by Check.
+const (
+	ProblemMissing = "missing" // the item of an entry is gone from the bin
+	ProblemOrphan  = "orphan"  // an item in a bin has no entry
+	ProblemSize    = "size"    // the recorded size is wrong
+	ProblemBadTime = "bad-time"
+)
+
+// Problem is an inconsistency between the index and the bins.
+type Problem struct {
+	Kind  string
+	Entry db.Entry // unset for orphans
+	Path  string   // the item in the bin
+	Size  int64    // the actual size, for ProblemSize
+}
+
+// Check compares the index with the contents of every bin. Items of
+// operations still in progress are not reported.
+func Check(b Backend, d *sql.DB) ([]Problem, error) {
+	entries, badTime, err := db.AllChecked(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	known, err := inFlight(b, d)
+	if err != nil {
+		return nil, err
+	}
+	// An entry whose item is gone is dropped, whatever its time.
+	for _, e := range badTime {
+		path := b.Path(e)
+		known[path] = true
+		kind := ProblemBadTime
+		if !exists(path) {
+			kind = ProblemMissing
+		}
+		problems = append(problems, Problem{Kind: kind, Entry: e, Path: path})
+	}
+	for _, e := range entries {
+		path := b.Path(e)
+		known[path] = true
+		info, err := os.Lstat(path)
+		if err != nil {
+			problems = append(problems, Problem{Kind: ProblemMissing, Entry: e, Path: path})
+			continue
+		}
+		if size := itemSize(path, info); size != e.SizeBytes {
+			problems = append(problems, Problem{Kind: ProblemSize, Entry: e, Path: path, Size: size})
+		}
+	}
+
+	bins, err := b.Bins(d)
+	if err != nil {
+		return nil, err
+	}
+	for _, binDir := range bins {
+		items, err := os.ReadDir(binDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			path := filepath.Join(binDir, item.Name())
+			if known[path] || strings.HasPrefix(item.Name(), ".toss-partial.") {
+				continue
+			}
+			problems = append(problems, Problem{Kind: ProblemOrphan, Path: path})
+		}
+	}
+	return problems, nil
+}
+
+// Repair fixes problems found by Check: orphans are adopted, entries whose
+// item is gone are dropped, and sizes and unreadable times are taken from
+// the item itself.
+func Repair(b Backend, d *sql.DB, problems []Problem) error {
+	for _, p := range problems {
+		var err error
+		switch p.Kind {
+		case ProblemMissing:
+			err = db.Remove(d, p.Entry.ID)
+		case ProblemSize:
+			err = db.SetSize(d, p.Entry.ID, p.Size)
+		case ProblemBadTime:
+			var t time.Time
+			if t, err = changeTime(p.Path); err == nil {
+				err = db.SetTossedAt(d, p.Entry.ID, t)
+			}
+		case ProblemOrphan:
+			err = adopt(b, d, p.Path)
+		}
+		if err != nil {
+			return fmt.Errorf("repairing %s: %w", p.Path, err)
+		}
+	}
+	return nil
+}
+
+func adopt(b Backend, d *sql.DB, path string) error {
+	e, err := b.Adopt(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if taken, err := db.FindByIDPrefix(d, e.ID); err != nil {
+		return err
+	} else if len(taken) > 0 {
+		e.ID = db.NewID()
+	}
+	return db.Append(d, e)
+}
+
+// splitBinName splits a native bin name, "<uuid>-<original name>".
+func splitBinName(name string) (id, base string, ok bool) {
+	const idLen = 36
+	if len(name) <= idLen+1 || name[idLen] != '-' {
+		return "", "", false
+	}
+	id = strings.ToLower(name[:idLen])
+	for i, c := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return "", "", false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return "", "", false
+			}
+		}
+	}
+	return id, name[idLen+1:], true
+}
+
+// changeTime approximates when an item was put in the bin: moving it there
+// updated its ctime.
+func changeTime(path string) (time.Time, error) {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(st.Ctim.Unix()), nil
+}
+
+// Rebuild regenerates the index from the bins, e.g. after toss.db was lost.
+// Every item is adopted again from its sidecar or .trashinfo file, or
+// failing that reconstructed from its name. It returns the number of items
+// indexed.
+func Rebuild(b Backend, d *sql.DB) (int, error) {
+	if err := db.Clear(d); err != nil {
+		return 0, fmt.Errorf("clearing db: %w", err)
+	}
+	if err := b.Sync(d); err != nil {
+		return 0, fmt.Errorf("syncing bin: %w", err)
+	}
+	problems, err := Check(b, d)
+	if err != nil {
+		return 0, err
+	}
+	var orphans []Problem
+	for _, p := range problems {
+		if p.Kind == ProblemOrphan {
+			orphans = append(orphans, p)
+		}
+	}
+	if err := Repair(b, d, orphans); err != nil {
+		return 0, err
+	}
+	entries, err := db.All(d)
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
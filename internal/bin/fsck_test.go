@@ -0,0 +1,222 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func problemKinds(problems []Problem) map[string]int {
+	kinds := make(map[string]int)
+	for _, p := range problems {
+		kinds[p.Kind]++
+	}
+	return kinds
+}
+
+func TestCheck_CleanBin(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	if _, err := TossItem(b, d, src); err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	problems, err := Check(b, d)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("want no problems, got %+v", problems)
+	}
+}
+
+func TestCheckAndRepair(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	var tossed []db.Entry
+	for _, name := range []string{"gone.txt", "resized.txt", "badtime.txt"} {
+		src := filepath.Join(dir, name)
+		writeFile(t, src, "data", 0644)
+		e, err := TossItem(b, d, src)
+		if err != nil {
+			t.Fatalf("TossItem: %v", err)
+		}
+		tossed = append(tossed, e)
+	}
+	if err := os.Remove(b.Path(tossed[0])); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	writeFile(t, b.Path(tossed[1]), "much more data", 0644)
+	if _, err := d.Exec(`UPDATE entries SET tossed_at = 'yesterday' WHERE id = ?`, tossed[2].ID); err != nil {
+		t.Fatalf("corrupting tossed_at: %v", err)
+	}
+	orphanID := db.NewID()
+	writeFile(t, filepath.Join(b.binDir, orphanID+"-orphan.txt"), "lost", 0644)
+
+	problems, err := Check(b, d)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	kinds := problemKinds(problems)
+	for _, kind := range []string{ProblemMissing, ProblemSize, ProblemBadTime, ProblemOrphan} {
+		if kinds[kind] != 1 {
+			t.Errorf("want 1 %s problem, got %d (%+v)", kind, kinds[kind], problems)
+		}
+	}
+
+	if err := Repair(b, d, problems); err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if problems, _ := Check(b, d); len(problems) != 0 {
+		t.Errorf("want no problems after Repair, got %+v", problems)
+	}
+
+	entries, err := db.All(d)
+	if err != nil {
+		t.Fatalf("All after Repair: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("want 3 entries after Repair, got %d", len(entries))
+	}
+	adopted, err := db.FindByIDPrefix(d, orphanID)
+	if err != nil || len(adopted) != 1 {
+		t.Fatalf("orphan should be adopted under its own ID: %v %v", adopted, err)
+	}
+	if want := filepath.Join(filepath.Dir(dir), "orphan.txt"); adopted[0].OriginalPath != want {
+		t.Errorf("adopted OriginalPath: want %q, got %q", want, adopted[0].OriginalPath)
+	}
+}
+
+func TestCheckAndRepair_MissingWithBadTime(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if _, err := d.Exec(`UPDATE entries SET tossed_at = 'yesterday' WHERE id = ?`, e.ID); err != nil {
+		t.Fatalf("corrupting tossed_at: %v", err)
+	}
+	if err := os.Remove(b.Path(e)); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	problems, err := Check(b, d)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(problems) != 1 || problems[0].Kind != ProblemMissing {
+		t.Fatalf("want the item reported missing, got %+v", problems)
+	}
+	if err := Repair(b, d, problems); err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if n := countEntries(t, d); n != 0 {
+		t.Errorf("want the entry dropped, got %d entries", n)
+	}
+}
+
+func TestCheck_IgnoresPartialCopies(t *testing.T) {
+	b, d, _ := newTestBin(t)
+	writeFile(t, filepath.Join(b.binDir, ".toss-partial.x"), "half", 0644)
+	problems, err := Check(b, d)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("want no problems, got %+v", problems)
+	}
+}
+
+func TestSplitBinName(t *testing.T) {
+	id := "3f2a1b4c-0d5e-4f60-8a7b-9c8d7e6f5a4b"
+	got, base, ok := splitBinName(id + "-notes.txt")
+	if !ok || got != id || base != "notes.txt" {
+		t.Errorf("splitBinName: got %q %q %v", got, base, ok)
+	}
+	for _, name := range []string{"notes.txt", id, "3f2a1b4c-0d5e-4f60-8a7b-9c8d7e6f5a4z-x", "3f2a1b4c_0d5e-4f60-8a7b-9c8d7e6f5a4b-x"} {
+		if _, _, ok := splitBinName(name); ok {
+			t.Errorf("splitBinName(%q): want not ok", name)
+		}
+	}
+}
+
+func TestFreedesktop_AdoptWritesTrashInfo(t *testing.T) {
+	b, _ := newTestTrash(t)
+	if err := b.home().ensureDirs(); err != nil {
+		t.Fatalf("ensureDirs: %v", err)
+	}
+	writeFile(t, filepath.Join(b.Dir(), "stray.txt"), "x", 0644)
+	e, err := b.Adopt(b.Dir(), "stray.txt")
+	if err != nil {
+		t.Fatalf("Adopt: %v", err)
+	}
+	if filepath.Base(e.OriginalPath) != "stray.txt" {
+		t.Errorf("OriginalPath: got %q", e.OriginalPath)
+	}
+	if _, err := os.Lstat(b.home().infoPath("stray.txt")); err != nil {
+		t.Errorf("trashinfo should exist: %v", err)
+	}
+}
+
+func TestRebuild_FromSidecars(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "project", "notes.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	// An item from before sidecars existed.
+	legacyID := db.NewID()
+	writeFile(t, filepath.Join(b.binDir, legacyID+"-old.txt"), "old", 0644)
+
+	if err := db.Clear(d); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	n, err := Rebuild(b, d)
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("want 2 items, got %d", n)
+	}
+	got, err := db.FindByIDPrefix(d, e.ID)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("FindByIDPrefix: %v %v", got, err)
+	}
+	if got[0].OriginalPath != src || !got[0].TossedAt.Equal(e.TossedAt.Truncate(time.Second)) {
+		t.Errorf("rebuilt entry: want %s at %v, got %+v", src, e.TossedAt, got[0])
+	}
+	if legacy, _ := db.FindByIDPrefix(d, legacyID); len(legacy) != 1 {
+		t.Errorf("legacy item should be indexed under its ID")
+	}
+}
+
+func TestRebuild_Freedesktop(t *testing.T) {
+	b, dir := newTestTrash(t)
+	d, err := db.Open(b.dbPath)
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+	src := filepath.Join(dir, "photo.jpg")
+	writeFile(t, src, "jpeg", 0644)
+	if _, err := TossItem(b, d, src); err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if err := db.Clear(d); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	n, err := Rebuild(b, d)
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	entries, _ := db.All(d)
+	if n != 1 || len(entries) != 1 || entries[0].OriginalPath != src {
+		t.Errorf("want %s reindexed, got %+v", src, entries)
+	}
+}
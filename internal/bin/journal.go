@@ -0,0 +1,191 @@
+package bin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// TossItem moves src into the bin and records it. The move is journaled
+// first, so if toss is killed half way Recover can finish or undo it.
+// If the item reached the bin but the original could not be fully removed,
+// the entry is recorded and returned along with the error.
+func TossItem(b Backend, d *sql.DB, src string) (db.Entry, error) {
+	e, err := b.Prepare(src)
+	if err != nil {
+		return db.Entry{}, err
+	}
+	op, err := db.Begin(d, db.OpToss, e, "")
+	if err != nil {
+		b.Delete(e)
+		return db.Entry{}, err
+	}
+
+	moveErr := moveItem(e.OriginalPath, b.Path(e))
+	if moveErr != nil && !exists(b.Path(e)) {
+		b.Delete(e)
+		db.Abandon(d, op)
+		return db.Entry{}, moveErr
+	}
+
+	if err := db.Commit(d, op, func(tx *sql.Tx) error { return db.Append(tx, e) }); err != nil {
+		return db.Entry{}, fmt.Errorf("recording %s: %w", e.OriginalPath, err)
+	}
+	return e, moveErr
+}
+
+// RestoreItem moves e out of the bin to dest, or merges it into the
+// directory at dest, and drops its record, journaled like TossItem. dest
+// must not exist unless merging.
+func RestoreItem(b Backend, d *sql.DB, e db.Entry, dest string, merge bool) error {
+	kind := db.OpRestore
+	if merge {
+		kind = db.OpMerge
+	}
+	op, err := db.Begin(d, kind, e, dest)
+	if err != nil {
+		return err
+	}
+
+	if merge {
+		err = b.Merge(e, dest)
+	} else {
+		err = b.Restore(e, dest)
+	}
+	// A plain restore has happened once dest exists, even if cleaning up
+	// the bin failed afterwards.
+	if err != nil && (merge || !exists(dest)) {
+		db.Abandon(d, op)
+		return err
+	}
+
+	if cerr := db.Commit(d, op, func(tx *sql.Tx) error { return db.Remove(tx, e.ID) }); cerr != nil {
+		return fmt.Errorf("updating db: %w", cerr)
+	}
+	return err
+}
+
+// DeleteItem permanently removes e from its bin and from the index.
+func DeleteItem(b Backend, d *sql.DB, e db.Entry) error {
+	op, err := db.Begin(d, db.OpDelete, e, "")
+	if err != nil {
+		return err
+	}
+	if err := b.Delete(e); err != nil {
+		db.Abandon(d, op)
+		return err
+	}
+	if err := db.Commit(d, op, func(tx *sql.Tx) error { return db.Remove(tx, e.ID) }); err != nil {
+		return fmt.Errorf("updating db: %w", err)
+	}
+	return nil
+}
+
+// Recover finishes or rolls back operations that were interrupted, judging
+// by where the item ended up. It returns a note for each one. The caller
+// must make sure no other toss process is running, or it would interfere
+// with their operations in progress.
+func Recover(b Backend, d *sql.DB) ([]string, error) {
+	ops, err := db.Pending(d)
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	var notes []string
+	for _, op := range ops {
+		note, err := recoverOp(b, d, op)
+		if err != nil {
+			return notes, fmt.Errorf("recovering %s of %s: %w", op.Kind, op.Entry.OriginalPath, err)
+		}
+		notes = append(notes, note)
+	}
+	return notes, db.PruneJournal(d)
+}
+
+func recoverOp(b Backend, d *sql.DB, op db.Op) (string, error) {
+	e := op.Entry
+	binPath := b.Path(e)
+	commitRemove := func(tx *sql.Tx) error { return db.Remove(tx, e.ID) }
+
+	switch op.Kind {
+	case db.OpToss:
+		// A copy is only renamed into the bin once complete, so anything
+		// under the partial name is unfinished.
+		if err := os.RemoveAll(partialPath(binPath)); err != nil {
+			return "", err
+		}
+		if !exists(binPath) {
+			if err := b.Delete(e); err != nil {
+				return "", err
+			}
+			return "rolled back tossing " + e.OriginalPath, db.Abandon(d, op.ID)
+		}
+		if err := db.Commit(d, op.ID, func(tx *sql.Tx) error { return db.Append(tx, e) }); err != nil {
+			return "", err
+		}
+		if exists(e.OriginalPath) {
+			return fmt.Sprintf("finished tossing %s; part of the original may remain there", e.OriginalPath), nil
+		}
+		return "finished tossing " + e.OriginalPath, nil
+
+	case db.OpRestore:
+		if err := os.RemoveAll(partialPath(op.Dest)); err != nil {
+			return "", err
+		}
+		if !exists(op.Dest) {
+			return "rolled back restoring " + e.OriginalPath, db.Abandon(d, op.ID)
+		}
+		// A rename leaves nothing behind, so an item still in the bin next
+		// to dest was only restored if it was copied across filesystems:
+		// the copy reaches dest complete, and the rest is its remainder.
+		// Otherwise something else is at dest, and the item stays.
+		if exists(binPath) && !Crosses(binPath, op.Dest) {
+			return fmt.Sprintf("rolled back restoring %s; %s is not the item", e.OriginalPath, op.Dest), db.Abandon(d, op.ID)
+		}
+		if err := b.Delete(e); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("finished restoring %s to %s", e.OriginalPath, op.Dest), db.Commit(d, op.ID, commitRemove)
+
+	case db.OpMerge:
+		if exists(binPath) {
+			return fmt.Sprintf("interrupted merging %s into %s; the rest is still in the bin", e.OriginalPath, op.Dest),
+				db.Abandon(d, op.ID)
+		}
+		if err := b.Delete(e); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("finished merging %s into %s", e.OriginalPath, op.Dest), db.Commit(d, op.ID, commitRemove)
+
+	case db.OpDelete:
+		if err := b.Delete(e); err != nil {
+			return "", err
+		}
+		return "finished deleting " + e.OriginalPath, db.Commit(d, op.ID, commitRemove)
+
+	default:
+		return "", fmt.Errorf("unknown journal operation %q", op.Kind)
+	}
+}
+
+// inFlight returns the bin paths of operations still pending, which may
+// belong to another toss process that is working on them right now.
+func inFlight(b Backend, d *sql.DB) (map[string]bool, error) {
+	ops, err := db.Pending(d)
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	paths := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		paths[b.Path(op.Entry)] = true
+	}
+	return paths, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,220 @@
+package bin
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func newTestBin(t *testing.T) (*tossBackend, *sql.DB, string) {
+	t.Helper()
+	dir := t.TempDir()
+	b := &tossBackend{binDir: filepath.Join(dir, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+	d, err := db.Open(b.dbPath)
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return b, d, dir
+}
+
+func countEntries(t *testing.T, d *sql.DB) int {
+	t.Helper()
+	entries, err := db.All(d)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	return len(entries)
+}
+
+func TestTossItem_RecordsAndClearsJournal(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if got := readFile(t, b.Path(e)); got != "data" {
+		t.Errorf("bin content: got %q", got)
+	}
+	if n := countEntries(t, d); n != 1 {
+		t.Errorf("want 1 entry, got %d", n)
+	}
+	if ops, _ := db.Pending(d); len(ops) != 0 {
+		t.Errorf("want no pending ops, got %d", len(ops))
+	}
+}
+
+func TestTossItem_MissingSourceLeavesNothing(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	if _, err := TossItem(b, d, filepath.Join(dir, "nope")); err == nil {
+		t.Fatal("expected error")
+	}
+	if ops, _ := db.Pending(d); len(ops) != 0 {
+		t.Errorf("want no pending ops, got %d", len(ops))
+	}
+}
+
+func TestRecover_FinishesTossThatReachedBin(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := b.Prepare(src)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if _, err := db.Begin(d, db.OpToss, e, ""); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	// Killed after the move, before the row was written.
+	if err := moveItem(src, b.Path(e)); err != nil {
+		t.Fatalf("moveItem: %v", err)
+	}
+
+	notes, err := Recover(b, d)
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if len(notes) != 1 {
+		t.Errorf("want 1 note, got %v", notes)
+	}
+	if n := countEntries(t, d); n != 1 {
+		t.Errorf("want the orphan adopted, got %d entries", n)
+	}
+	if ops, _ := db.Pending(d); len(ops) != 0 {
+		t.Errorf("want no pending ops, got %d", len(ops))
+	}
+}
+
+func TestRecover_RollsBackUnfinishedCopy(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "tree")
+	writeFile(t, filepath.Join(src, "f.txt"), "data", 0644)
+	e, err := b.Prepare(src)
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if _, err := db.Begin(d, db.OpToss, e, ""); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	// Killed half way through a cross-filesystem copy.
+	writeFile(t, filepath.Join(partialPath(b.Path(e)), "f.txt"), "da", 0644)
+
+	if _, err := Recover(b, d); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if _, err := os.Lstat(partialPath(b.Path(e))); !os.IsNotExist(err) {
+		t.Error("partial copy should be removed")
+	}
+	if got := readFile(t, filepath.Join(src, "f.txt")); got != "data" {
+		t.Errorf("original should be untouched, got %q", got)
+	}
+	if n := countEntries(t, d); n != 0 {
+		t.Errorf("want no entries, got %d", n)
+	}
+}
+
+func TestRecover_FinishesRestore(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if _, err := db.Begin(d, db.OpRestore, e, src); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := b.Restore(e, src); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if _, err := Recover(b, d); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if n := countEntries(t, d); n != 0 {
+		t.Errorf("want restored entry removed, got %d", n)
+	}
+}
+
+func TestRecover_RollsBackRestoreThatNeverStarted(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if _, err := db.Begin(d, db.OpRestore, e, src); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	if _, err := Recover(b, d); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if n := countEntries(t, d); n != 1 {
+		t.Errorf("want entry kept, got %d", n)
+	}
+	if got := readFile(t, b.Path(e)); got != "data" {
+		t.Errorf("item should stay in bin, got %q", got)
+	}
+}
+
+func TestRecover_KeepsItemWhenDestIsSomethingElse(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if _, err := db.Begin(d, db.OpRestore, e, src); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	// Killed before the rename, and then another file took the path.
+	writeFile(t, src, "other", 0644)
+
+	if _, err := Recover(b, d); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if got := readFile(t, b.Path(e)); got != "data" {
+		t.Errorf("item should stay in bin, got %q", got)
+	}
+	if n := countEntries(t, d); n != 1 {
+		t.Errorf("want entry kept, got %d", n)
+	}
+	if got := readFile(t, src); got != "other" {
+		t.Errorf("dest should be untouched, got %q", got)
+	}
+}
+
+func TestRecover_FinishesDelete(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "tree")
+	writeFile(t, filepath.Join(src, "f.txt"), "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if _, err := db.Begin(d, db.OpDelete, e, ""); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	// Killed part way through removing the tree.
+	if err := os.Remove(filepath.Join(b.Path(e), "f.txt")); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	if _, err := Recover(b, d); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if _, err := os.Lstat(b.Path(e)); !os.IsNotExist(err) {
+		t.Error("item should be gone")
+	}
+	if n := countEntries(t, d); n != 0 {
+		t.Errorf("want entry removed, got %d", n)
+	}
+}
@@ -0,0 +1,69 @@
+package bin
+
+import (
+	"io/fs"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// setFileMeta records the item's own metadata on e from its Lstat info.
+func setFileMeta(e *db.Entry, path string, info fs.FileInfo) {
+	e.Mode = info.Mode()
+	e.ModTime = info.ModTime()
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		e.UID = int(st.Uid)
+		e.GID = int(st.Gid)
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		e.LinkTarget, _ = os.Readlink(path)
+	}
+}
+
+// invocation describes the toss invocation itself. It is the same for every
+// item of a run, so it is worked out once.
+var invocation = sync.OnceValue(func() db.Entry {
+	var c db.Entry
+	c.Hostname, _ = os.Hostname()
+	c.Cwd, _ = os.Getwd()
+	c.Command = commandLine(os.Args)
+	c.Batch = db.NewID()
+	if u, err := user.Current(); err == nil {
+		c.User = u.Username
+	} else {
+		c.User = os.Getenv("USER")
+	}
+	return c
+})
+
+// setContext records who tossed e, where, with which command and in which
+// batch.
+func setContext(e *db.Entry) {
+	c := invocation()
+	e.Hostname, e.Cwd, e.Command, e.User, e.Batch = c.Hostname, c.Cwd, c.Command, c.User, c.Batch
+}
+
+// commandLine joins args the way a shell would need them quoted.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,82 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func TestTossItem_RecordsMetadata(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "run.sh")
+	writeFile(t, src, "#!/bin/sh\n", 0750)
+	mtime := time.Date(2025, 6, 7, 8, 9, 10, 0, time.Local)
+	if err := os.Chtimes(src, mtime, mtime); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	if _, err := TossItem(b, d, src); err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+
+	entries, err := db.All(d)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("All: %v (%d entries)", err, len(entries))
+	}
+	e := entries[0]
+	if e.Mode != 0750 {
+		t.Errorf("Mode: want %v, got %v", os.FileMode(0750), e.Mode)
+	}
+	if e.UID != os.Getuid() || e.GID != os.Getgid() {
+		t.Errorf("owner: want %d:%d, got %d:%d", os.Getuid(), os.Getgid(), e.UID, e.GID)
+	}
+	if !e.ModTime.Equal(mtime) {
+		t.Errorf("ModTime: want %v, got %v", mtime, e.ModTime)
+	}
+	wd, _ := os.Getwd()
+	if e.Cwd != wd || e.Command == "" || e.User == "" {
+		t.Errorf("context not recorded: cwd %q, command %q, user %q", e.Cwd, e.Command, e.User)
+	}
+}
+
+func TestTossItem_RecordsLinkTarget(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "link")
+	if err := os.Symlink("elsewhere/target.txt", src); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if e.LinkTarget != "elsewhere/target.txt" || e.Mode&os.ModeSymlink == 0 {
+		t.Errorf("want a symlink to elsewhere/target.txt, got mode %v target %q", e.Mode, e.LinkTarget)
+	}
+}
+
+func TestTossItem_SameRunSameBatch(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	var batches []string
+	for _, name := range []string{"a", "b"} {
+		src := filepath.Join(dir, name)
+		writeFile(t, src, name, 0644)
+		e, err := TossItem(b, d, src)
+		if err != nil {
+			t.Fatalf("TossItem: %v", err)
+		}
+		batches = append(batches, e.Batch)
+	}
+	if batches[0] == "" || batches[0] != batches[1] {
+		t.Errorf("want one batch for the run, got %q", batches)
+	}
+}
+
+func TestCommandLine_Quotes(t *testing.T) {
+	got := commandLine([]string{"toss", "-r", "my file.txt", "it's", "", "a/b_c.go"})
+	want := `toss -r 'my file.txt' 'it'\''s' '' a/b_c.go`
+	if got != want {
+		t.Errorf("commandLine: want %s, got %s", want, got)
+	}
+}
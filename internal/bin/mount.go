@@ -0,0 +1,169 @@
+package bin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func deviceOf(path string) (uint64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("%s: no device information", path)
+	}
+	return uint64(st.Dev), nil
+}
+
+// mountPoint returns the top directory of the filesystem holding dir.
+func mountPoint(dir string) (string, error) {
+	dev, err := deviceOf(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, nil
+		}
+		parentDev, err := deviceOf(parent)
+		if err != nil {
+			return "", err
+		}
+		if parentDev != dev {
+			return dir, nil
+		}
+		dir = parent
+	}
+}
+
+// foreignTopdir reports the mount point of abs when it lives on a different
+// filesystem than homeBin, i.e. when moving it home would need a copy.
+func foreignTopdir(abs, homeBin string) (string, bool) {
+	parent := filepath.Dir(abs)
+	srcDev, err := deviceOf(parent)
+	if err != nil {
+		return "", false
+	}
+	homeDev, err := deviceOf(existingDir(homeBin))
+	if err != nil || srcDev == homeDev {
+		return "", false
+	}
+	topdir, err := mountPoint(parent)
+	if err != nil {
+		return "", false
+	}
+	return topdir, true
+}
+
+// ownedDir creates dir if needed and checks that it is a real directory
+// owned by the current user, so a bin on a shared filesystem cannot be
+// planted by someone else.
+func ownedDir(dir string) bool {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false
+	}
+	return owned(dir)
+}
+
+// mayOwn reports whether ownedDir(dir) would succeed, without creating dir.
+func mayOwn(dir string) bool {
+	if _, err := os.Lstat(dir); err == nil {
+		return owned(dir)
+	}
+	return unix.Access(existingDir(filepath.Dir(dir)), unix.W_OK) == nil
+}
+
+func owned(dir string) bool {
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
+
+// existingDir returns path, or its nearest ancestor that exists.
+func existingDir(path string) string {
+	for {
+		if _, err := os.Lstat(path); err == nil || path == filepath.Dir(path) {
+			return path
+		}
+		path = filepath.Dir(path)
+	}
+}
+
+// Crosses reports whether moving src to dest would copy it across
+// filesystems rather than rename it. dest need not exist yet.
+func Crosses(src, dest string) bool {
+	from, err := deviceOf(existingDir(filepath.Dir(src)))
+	if err != nil {
+		return false
+	}
+	to, err := deviceOf(existingDir(filepath.Dir(dest)))
+	return err == nil && from != to
+}
+
+// pseudoFS lists filesystem types that never hold user files.
+var pseudoFS = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true,
+	"cgroup2": true, "configfs": true, "debugfs": true, "devpts": true,
+	"devtmpfs": true, "fusectl": true, "hugetlbfs": true, "mqueue": true,
+	"nsfs": true, "proc": true, "pstore": true, "securityfs": true,
+	"sysfs": true, "tracefs": true,
+}
+
+// mounts lists mount points from /proc/self/mounts. Systems without it
+// yield no mounts.
+func mounts() ([]string, error) {
+	f, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || pseudoFS[fields[2]] {
+			continue
+		}
+		points = append(points, unescapeMount(fields[1]))
+	}
+	return points, scanner.Err()
+}
+
+// unescapeMount decodes the octal escapes (\040 for space etc.) used in
+// /proc/self/mounts.
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
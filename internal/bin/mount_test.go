@@ -0,0 +1,103 @@
+package bin
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func TestUnescapeMount(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"/":                     "/",
+		`/media/usb\040stick`:   "/media/usb stick",
+		`/mnt/tab\011and\134bs`: "/mnt/tab\tand\\bs",
+		`/mnt/trailing\04`:      `/mnt/trailing\04`,
+	}
+	for in, want := range cases {
+		if got := unescapeMount(in); got != want {
+			t.Errorf("unescapeMount(%q): want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestMountPoint_IsAncestor(t *testing.T) {
+	dir := t.TempDir()
+	mp, err := mountPoint(dir)
+	if err != nil {
+		t.Fatalf("mountPoint: %v", err)
+	}
+	if !filepath.IsAbs(mp) {
+		t.Fatalf("mount point should be absolute, got %q", mp)
+	}
+	rel, err := filepath.Rel(mp, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		t.Errorf("mount point %q is not an ancestor of %q", mp, dir)
+	}
+}
+
+func TestForeignTopdir_SameFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	if err := EnsureDirs(binDir); err != nil {
+		t.Fatalf("EnsureDirs: %v", err)
+	}
+	if topdir, ok := foreignTopdir(filepath.Join(dir, "file.txt"), binDir); ok {
+		t.Errorf("same filesystem should not be foreign, got topdir %q", topdir)
+	}
+}
+
+func TestTossBackend_MoveRecordsBinDir(t *testing.T) {
+	dir := t.TempDir()
+	b := &tossBackend{binDir: filepath.Join(dir, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+	src := filepath.Join(dir, "file.txt")
+	writeFile(t, src, "x", 0644)
+	entry, err := moveInto(b, src)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if entry.BinDir != b.binDir {
+		t.Errorf("BinDir: want %q, got %q", b.binDir, entry.BinDir)
+	}
+	if got := readFile(t, b.Path(entry)); got != "x" {
+		t.Errorf("content at Path: want 'x', got %q", got)
+	}
+}
+
+func TestTossBackend_PathDefaultsToHomeBin(t *testing.T) {
+	b := &tossBackend{binDir: "/home/u/.toss/files"}
+	legacy := b.Path(db.Entry{ID: "id", OriginalPath: "/file.txt", BinName: "id-file.txt"})
+	if legacy != "/home/u/.toss/files/id-file.txt" {
+		t.Errorf("legacy entry path: got %q", legacy)
+	}
+}
+
+func TestFreedesktop_TrashAt(t *testing.T) {
+	b := &freedesktopBackend{trashDir: "/home/u/.local/share/Trash"}
+	cases := []struct {
+		files  string
+		dir    string
+		topdir string
+	}{
+		{"/home/u/.local/share/Trash/files", "/home/u/.local/share/Trash", ""},
+		{"/mnt/usb/.Trash-1000/files", "/mnt/usb/.Trash-1000", "/mnt/usb"},
+		{"/mnt/usb/.Trash/1000/files", "/mnt/usb/.Trash/1000", "/mnt/usb"},
+	}
+	for _, c := range cases {
+		got := b.trashAt(c.files)
+		if got.dir != c.dir || got.topdir != c.topdir {
+			t.Errorf("trashAt(%q): want {%q %q}, got {%q %q}", c.files, c.dir, c.topdir, got.dir, got.topdir)
+		}
+	}
+}
+
+func TestCrosses_SameFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "file.txt")
+	writeFile(t, src, "x", 0644)
+	if Crosses(src, filepath.Join(dir, "not", "yet", "there")) {
+		t.Error("a destination below the same directory should not cross filesystems")
+	}
+}
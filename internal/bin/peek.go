@@ -0,0 +1,58 @@
+package bin
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// InnerPath returns where inner, a path inside the tossed directory e, is
+// in the bin. inner is relative to the directory, or an absolute path
+// below its original location; it cannot lead out of the item, by ".." or
+// through a symlink inside it. inner may itself be a symlink, which is
+// returned as is.
+func InnerPath(b Backend, e db.Entry, inner string) (string, error) {
+	if inner == "" {
+		return b.Path(e), nil
+	}
+	if !e.IsDir {
+		return "", fmt.Errorf("%s is not a directory", e.OriginalPath)
+	}
+	rel := filepath.Clean(inner)
+	if filepath.IsAbs(rel) {
+		r, err := filepath.Rel(e.OriginalPath, rel)
+		if err != nil {
+			return "", err
+		}
+		rel = r
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s is not inside %s", inner, e.OriginalPath)
+	}
+	path := b.Path(e)
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if err != nil {
+			return "", fmt.Errorf("%s: no such file in %s", inner, e.OriginalPath)
+		}
+		if i < len(parts)-1 && info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s: %s is a symlink, which may lead out of %s",
+				inner, filepath.Join(parts[:i+1]...), e.OriginalPath)
+		}
+	}
+	return path, nil
+}
+
+// Extract copies src out of the bin to dest, keeping its metadata.
+func Extract(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("recreating parent dirs: %w", err)
+	}
+	return copyInto(src, dest)
+}
@@ -0,0 +1,101 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInnerPath(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "proj")
+	writeFile(t, filepath.Join(src, "src", "main.go"), "package main\n", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	want := filepath.Join(b.Path(e), "src", "main.go")
+
+	for _, inner := range []string{"src/main.go", "./src/../src/main.go", filepath.Join(src, "src", "main.go")} {
+		got, err := InnerPath(b, e, inner)
+		if err != nil || got != want {
+			t.Errorf("InnerPath(%q): want %q, got %q (%v)", inner, want, got, err)
+		}
+	}
+	for _, inner := range []string{"../escape", "src/../../escape", "/etc/passwd", "src/missing.go"} {
+		if got, err := InnerPath(b, e, inner); err == nil {
+			t.Errorf("InnerPath(%q): want an error, got %q", inner, got)
+		}
+	}
+}
+
+func TestInnerPath_Symlinks(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	outside := filepath.Join(dir, "outside")
+	writeFile(t, filepath.Join(outside, "secret"), "s", 0600)
+	src := filepath.Join(dir, "proj")
+	writeFile(t, filepath.Join(src, "real", "f.txt"), "f", 0644)
+	if err := os.Symlink(outside, filepath.Join(src, "out")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	if err := os.Symlink("real", filepath.Join(src, "in")); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+
+	for _, inner := range []string{"out/secret", "in/f.txt"} {
+		if got, err := InnerPath(b, e, inner); err == nil {
+			t.Errorf("InnerPath(%q): want an error, got %q", inner, got)
+		}
+	}
+	// The link itself is part of the item.
+	if got, err := InnerPath(b, e, "out"); err != nil || got != filepath.Join(b.Path(e), "out") {
+		t.Errorf("InnerPath(out): want the link, got %q (%v)", got, err)
+	}
+}
+
+func TestInnerPath_FileItem(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "x", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if got, err := InnerPath(b, e, ""); err != nil || got != b.Path(e) {
+		t.Errorf("InnerPath with no inner path: want %q, got %q (%v)", b.Path(e), got, err)
+	}
+	if _, err := InnerPath(b, e, "x"); err == nil {
+		t.Error("a file has no inner paths")
+	}
+}
+
+func TestExtract_LeavesItemInBin(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "proj")
+	writeFile(t, filepath.Join(src, "conf", "app.yml"), "port: 80\n", 0600)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	inner, err := InnerPath(b, e, "conf/app.yml")
+	if err != nil {
+		t.Fatalf("InnerPath: %v", err)
+	}
+	dest := filepath.Join(dir, "out", "app.yml")
+	if err := Extract(inner, dest); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if got := readFile(t, dest); got != "port: 80\n" {
+		t.Errorf("extracted content: got %q", got)
+	}
+	if info, err := os.Stat(dest); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("extracted mode: want 0600, got %v (%v)", info.Mode(), err)
+	}
+	if _, err := os.Lstat(inner); err != nil {
+		t.Errorf("the item should still hold the file: %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package bin
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/roman91DE/toss/internal/config"
+)
+
+// DefaultProtected are the system directories toss refuses to toss unless
+// told to insist. The home directory is protected too.
+var DefaultProtected = []string{
+	"/", "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib32", "/lib64",
+	"/libx32", "/media", "/mnt", "/opt", "/proc", "/root", "/run", "/sbin",
+	"/snap", "/srv", "/sys", "/tmp", "/usr", "/usr/bin", "/usr/lib",
+	"/usr/local", "/usr/sbin", "/usr/share", "/var", "/var/lib", "/var/log",
+}
+
+// A Refusal is why a path must not be tossed.
+type Refusal struct {
+	Path   string
+	Reason string
+	// Insistable refusals can be overridden by the user, e.g. with
+	// --no-preserve-root; the others would break the bin.
+	Insistable bool
+}
+
+func (r *Refusal) Error() string {
+	return fmt.Sprintf("refusing to toss %s: %s", r.Path, r.Reason)
+}
+
+// A Guard decides whether paths may be tossed.
+type Guard struct {
+	bins      []string // directories holding a bin and its index
+	protected []string
+	mounts    []string
+}
+
+// NewGuard returns a guard for the bins of b, with the protected paths
+// configured in cfg.
+func NewGuard(b Backend, d *sql.DB, cfg config.Config) (*Guard, error) {
+	g := &Guard{bins: []string{filepath.Dir(b.DBPath()), b.Root()}}
+	dirs, err := b.Bins(d)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		g.bins = append(g.bins, filepath.Dir(dir))
+	}
+	if cfg.ProtectDefaults {
+		g.protected = append(g.protected, DefaultProtected...)
+		if home, err := os.UserHomeDir(); err == nil {
+			g.protected = append(g.protected, home)
+		}
+	}
+	g.protected = append(g.protected, cfg.Protect...)
+	if g.mounts, err = mounts(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Check returns a *Refusal if path must not be tossed: if it is inside a
+// bin or holds one, or, unless insist is set, if it is a protected path or
+// a mount point. Paths are compared both as given, made absolute, and with
+// the symlinks in their directory resolved.
+func (g *Guard) Check(path string, insist bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	names := []string{abs}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		if real := filepath.Join(dir, filepath.Base(abs)); real != abs {
+			names = append(names, real)
+		}
+	}
+
+	for _, name := range names {
+		for _, bin := range g.bins {
+			switch {
+			case within(name, bin):
+				return &Refusal{Path: path, Reason: "it is part of the bin " + bin}
+			case within(bin, name):
+				return &Refusal{Path: path, Reason: "it contains the bin " + bin}
+			}
+		}
+	}
+	if insist {
+		return nil
+	}
+	for _, name := range names {
+		if slices.Contains(g.protected, name) {
+			return &Refusal{Path: path, Reason: "it is a protected path", Insistable: true}
+		}
+		if g.isMountPoint(name) {
+			return &Refusal{Path: path, Reason: "it is a mount point", Insistable: true}
+		}
+	}
+	return nil
+}
+
+func (g *Guard) isMountPoint(path string) bool {
+	if slices.Contains(g.mounts, path) {
+		return true
+	}
+	info, err := os.Lstat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	dev, err := deviceOf(path)
+	if err != nil {
+		return false
+	}
+	parent, err := deviceOf(filepath.Dir(path))
+	return err == nil && dev != parent
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, "/")+"/")
+}
@@ -0,0 +1,71 @@
+package bin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGuard_Check(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	bin := filepath.Join(home, ".toss")
+	etc := filepath.Join(dir, "etc")
+	for _, d := range []string{bin, etc} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g := &Guard{bins: []string{bin}, protected: []string{etc}}
+
+	cases := []struct {
+		path       string
+		reason     string
+		insistable bool
+	}{
+		{path: filepath.Join(bin, "files", "x"), reason: "it is part of the bin " + bin},
+		{path: bin, reason: "it is part of the bin " + bin},
+		{path: home, reason: "it contains the bin " + bin},
+		{path: etc, reason: "it is a protected path", insistable: true},
+		{path: filepath.Join(etc, "passwd")},
+		{path: filepath.Join(home, ".tossrc")},
+	}
+	for _, c := range cases {
+		err := g.Check(c.path, false)
+		var refusal *Refusal
+		switch {
+		case c.reason == "" && err != nil:
+			t.Errorf("Check(%s): %v", c.path, err)
+		case c.reason == "":
+		case !errors.As(err, &refusal):
+			t.Errorf("Check(%s): want refusal %q, got %v", c.path, c.reason, err)
+		case refusal.Reason != c.reason || refusal.Insistable != c.insistable:
+			t.Errorf("Check(%s): want %q (insistable %v), got %q (insistable %v)",
+				c.path, c.reason, c.insistable, refusal.Reason, refusal.Insistable)
+		}
+	}
+
+	if err := g.Check(etc, true); err != nil {
+		t.Errorf("Check(%s, insist): %v", etc, err)
+	}
+	if err := g.Check(home, true); err == nil {
+		t.Errorf("Check(%s, insist): the bin's parent must be refused", home)
+	}
+}
+
+func TestGuard_CheckResolvesSymlinkedDirs(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "real", ".toss")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(filepath.Join(dir, "real"), link); err != nil {
+		t.Fatal(err)
+	}
+	g := &Guard{bins: []string{bin}}
+	if err := g.Check(filepath.Join(link, ".toss"), false); err == nil {
+		t.Error("a bin reached through a symlinked directory was not refused")
+	}
+}
@@ -0,0 +1,58 @@
+package bin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// In the native layout every item has a sidecar, <bin root>/info/<bin
+// name>.json, holding its entry, so the index can be rebuilt from the bin
+// if toss.db is lost.
+
+func infoDir(binDir string) string {
+	return filepath.Join(filepath.Dir(binDir), "info")
+}
+
+func sidecarPath(binDir, binName string) string {
+	return filepath.Join(infoDir(binDir), binName+".json")
+}
+
+func writeSidecar(e db.Entry) error {
+	path := sidecarPath(e.BinDir, e.BinName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating info dir: %w", err)
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing sidecar: %w", err)
+	}
+	return nil
+}
+
+func readSidecar(binDir, binName string) (db.Entry, error) {
+	data, err := os.ReadFile(sidecarPath(binDir, binName))
+	if err != nil {
+		return db.Entry{}, err
+	}
+	var e db.Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return db.Entry{}, fmt.Errorf("parsing sidecar: %w", err)
+	}
+	return e, nil
+}
+
+func removeSidecar(binDir, binName string) error {
+	if err := os.Remove(sidecarPath(binDir, binName)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing sidecar: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSidecar_WrittenOnTossAndRemovedOnRestore(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	side, err := readSidecar(e.BinDir, e.BinName)
+	if err != nil {
+		t.Fatalf("readSidecar: %v", err)
+	}
+	if side.ID != e.ID || side.OriginalPath != src || !side.TossedAt.Equal(e.TossedAt) {
+		t.Errorf("sidecar: want %+v, got %+v", e, side)
+	}
+
+	if err := RestoreItem(b, d, e, src, false); err != nil {
+		t.Fatalf("RestoreItem: %v", err)
+	}
+	if _, err := os.Lstat(sidecarPath(e.BinDir, e.BinName)); !os.IsNotExist(err) {
+		t.Error("sidecar should be removed after restore")
+	}
+}
+
+func TestSidecar_RemovedOnDelete(t *testing.T) {
+	b, d, dir := newTestBin(t)
+	src := filepath.Join(dir, "a.txt")
+	writeFile(t, src, "data", 0644)
+	e, err := TossItem(b, d, src)
+	if err != nil {
+		t.Fatalf("TossItem: %v", err)
+	}
+	if err := DeleteItem(b, d, e); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+	if _, err := os.Lstat(sidecarPath(e.BinDir, e.BinName)); !os.IsNotExist(err) {
+		t.Error("sidecar should be removed after delete")
+	}
+}
+
+func TestSidecar_RemovedWhenTossFails(t *testing.T) {
+	_, d, dir := newTestBin(t)
+	// A bin inside the directory being tossed: the sidecar is written, and
+	// then the move fails, as a directory cannot be moved into itself.
+	src := filepath.Join(dir, "tree")
+	writeFile(t, filepath.Join(src, "f.txt"), "data", 0644)
+	b := &tossBackend{binDir: filepath.Join(src, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+
+	if _, err := TossItem(b, d, src); err == nil {
+		t.Fatal("expected error")
+	}
+	entries, err := os.ReadDir(infoDir(b.binDir))
+	if err != nil {
+		t.Fatalf("the sidecar should have been written first: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("want no sidecars, got %d", len(entries))
+	}
+	if got := readFile(t, filepath.Join(src, "f.txt")); got != "data" {
+		t.Errorf("original should be untouched, got %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package bin
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// stressWork opens the bin on its own, as a separate toss process would,
+// then tosses the given number of files and restores every other one.
+func stressWork(dir string, worker, items int) error {
+	b := &tossBackend{binDir: filepath.Join(dir, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+	d, err := db.Open(b.dbPath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	for i := 0; i < items; i++ {
+		src := filepath.Join(dir, "src", fmt.Sprintf("w%d-%d.txt", worker, i))
+		if err := os.WriteFile(src, []byte(src), 0644); err != nil {
+			return err
+		}
+		e, err := TossItem(b, d, src)
+		if err != nil {
+			return fmt.Errorf("toss %s: %w", src, err)
+		}
+		if i%2 == 0 {
+			if err := RestoreItem(b, d, e, src, false); err != nil {
+				return fmt.Errorf("restore %s: %w", src, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkStressResult(t *testing.T, dir string, workers, items int) {
+	t.Helper()
+	b := &tossBackend{binDir: filepath.Join(dir, "bin"), dbPath: filepath.Join(dir, "toss.db")}
+	d, err := db.Open(b.dbPath)
+	if err != nil {
+		t.Fatalf("db.Open: %v", err)
+	}
+	defer d.Close()
+
+	entries, err := db.All(d)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if want := workers * items / 2; len(entries) != want {
+		t.Errorf("want %d entries, got %d", want, len(entries))
+	}
+	problems, err := Check(b, d)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("bin inconsistent after concurrent use: %+v", problems)
+	}
+	for w := 0; w < workers; w++ {
+		for i := 0; i < items; i += 2 {
+			src := filepath.Join(dir, "src", fmt.Sprintf("w%d-%d.txt", w, i))
+			if got := readFile(t, src); got != src {
+				t.Errorf("restored %s: got %q", src, got)
+			}
+		}
+	}
+}
+
+func TestStress_Goroutines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	const workers, items = 16, 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs <- stressWork(dir, w, items)
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	checkStressResult(t, dir, workers, items)
+}
+
+func TestStress_Processes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	const workers, items = 8, 10
+
+	cmds := make([]*exec.Cmd, workers)
+	for w := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestStressHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			"TOSS_STRESS_DIR="+dir,
+			"TOSS_STRESS_WORKER="+strconv.Itoa(w),
+			"TOSS_STRESS_ITEMS="+strconv.Itoa(items))
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("starting worker: %v", err)
+		}
+		cmds[w] = cmd
+	}
+	for w, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("worker %d: %v", w, err)
+		}
+	}
+	checkStressResult(t, dir, workers, items)
+}
+
+// TestStressHelperProcess is the body of each TestStress_Processes worker.
+func TestStressHelperProcess(t *testing.T) {
+	dir := os.Getenv("TOSS_STRESS_DIR")
+	if dir == "" {
+		t.Skip("helper process for TestStress_Processes")
+	}
+	worker, _ := strconv.Atoi(os.Getenv("TOSS_STRESS_WORKER"))
+	items, _ := strconv.Atoi(os.Getenv("TOSS_STRESS_ITEMS"))
+	if err := stressWork(dir, worker, items); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,59 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// A Tally is what tossing some paths would move.
+type Tally struct {
+	Paths int
+	Size  int64
+	Files int // files in all, counting those inside directories
+
+	// Crowded is the directory holding the most files, and Dense how many.
+	Crowded string
+	Dense   int
+
+	// Parent is the directory most paths are in, and Siblings how many.
+	Parent   string
+	Siblings int
+}
+
+// Measure tallies paths before they are tossed. Paths that cannot be
+// read are left out. Directories are only walked when withContents is set,
+// as that reads every file below them.
+func Measure(paths []string, withContents bool) Tally {
+	var t Tally
+	parents := map[string]int{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		info, err := os.Lstat(abs)
+		if err != nil {
+			continue
+		}
+		t.Paths++
+		parent := filepath.Dir(abs)
+		if parents[parent]++; parents[parent] > t.Siblings {
+			t.Parent, t.Siblings = parent, parents[parent]
+		}
+		if !info.IsDir() {
+			t.Size += info.Size()
+			t.Files++
+			continue
+		}
+		if !withContents {
+			continue
+		}
+		size, files, _ := dirUsage(abs)
+		t.Size += size
+		t.Files += files
+		if files > t.Dense {
+			t.Crowded, t.Dense = abs, files
+		}
+	}
+	return t
+}
@@ -0,0 +1,37 @@
+package bin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMeasure(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.log"), "aaaa", 0644)
+	writeFile(t, filepath.Join(dir, "b.log"), "bb", 0644)
+	if err := os.MkdirAll(filepath.Join(dir, "big", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"1", "2", "sub/3"} {
+		writeFile(t, filepath.Join(dir, "big", name), "x", 0644)
+	}
+	paths := []string{
+		filepath.Join(dir, "a.log"),
+		filepath.Join(dir, "b.log"),
+		filepath.Join(dir, "big"),
+		filepath.Join(dir, "missing"),
+	}
+
+	got := Measure(paths, true)
+	want := Tally{Paths: 3, Size: 9, Files: 5, Crowded: filepath.Join(dir, "big"), Dense: 3, Parent: dir, Siblings: 3}
+	if got != want {
+		t.Errorf("Measure:\n got %+v\nwant %+v", got, want)
+	}
+
+	got = Measure(paths, false)
+	want = Tally{Paths: 3, Size: 6, Files: 2, Parent: dir, Siblings: 3}
+	if got != want {
+		t.Errorf("Measure without contents:\n got %+v\nwant %+v", got, want)
+	}
+}
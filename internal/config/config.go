@@ -0,0 +1,271 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BackendToss        = "toss"
+	BackendFreedesktop = "freedesktop"
+)
+
+type Config struct {
+	Backend   string
+	Retention Retention
+	AutoGC    bool
+
+	// Protect lists paths toss refuses to toss on top of the built-in
+	// ones, which ProtectDefaults false drops.
+	Protect         []string
+	ProtectDefaults bool
+
+	Confirm Thresholds
+}
+
+// Retention limits what the bin may hold. Zero values mean no limit.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxSize  int64
+	MaxItems int
+}
+
+func (r Retention) IsZero() bool {
+	return r == Retention{}
+}
+
+// Thresholds are how much a single toss may move before toss asks for
+// confirmation. Zero values never ask.
+type Thresholds struct {
+	Args  int   // operands on the command line, e.g. from a glob
+	Size  int64 // bytes in all
+	Files int   // files inside any one directory
+}
+
+// DefaultThresholds are generous enough that only mistakes trip them.
+var DefaultThresholds = Thresholds{Args: 50, Size: 10 << 30, Files: 10000}
+
+func Default() Config {
+	return Config{Backend: BackendToss, ProtectDefaults: true, Confirm: DefaultThresholds}
+}
+
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding home dir: %w", err)
+	}
+	return filepath.Join(home, ".toss", "config"), nil
+}
+
+// Load reads ~/.toss/config. A missing file yields the defaults.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Config{}, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("opening config: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := Parse(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads "key = value" lines. Blank lines and lines starting with '#'
+// are ignored.
+func Parse(r io.Reader) (Config, error) {
+	cfg := Default()
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		if err := cfg.set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return Config{}, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "backend":
+		switch value {
+		case BackendToss, BackendFreedesktop:
+			c.Backend = value
+		default:
+			return fmt.Errorf("unknown backend %q", value)
+		}
+	case "max_age":
+		d, err := ParseAge(value)
+		if err != nil {
+			return err
+		}
+		c.Retention.MaxAge = d
+	case "max_size":
+		n, err := ParseSize(value)
+		if err != nil {
+			return err
+		}
+		c.Retention.MaxSize = n
+	case "max_items":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid max_items %q", value)
+		}
+		c.Retention.MaxItems = n
+	case "auto_gc":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid auto_gc %q", value)
+		}
+		c.AutoGC = b
+	case "protect":
+		path := value
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("finding home dir: %w", err)
+			}
+			path = filepath.Join(home, path[1:])
+		}
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("protect needs an absolute path, got %q", value)
+		}
+		c.Protect = append(c.Protect, filepath.Clean(path))
+	case "protect_defaults":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid protect_defaults %q", value)
+		}
+		c.ProtectDefaults = b
+	case "confirm_args":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid confirm_args %q", value)
+		}
+		c.Confirm.Args = n
+	case "confirm_size":
+		n, err := ParseSize(value)
+		if err != nil {
+			return err
+		}
+		c.Confirm.Size = n
+	case "confirm_files":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid confirm_files %q", value)
+		}
+		c.Confirm.Files = n
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return nil
+}
+
+// ParseSize parses sizes like "512", "20KB", "1.5G" or "2TB". Units are
+// powers of 1024, matching ui.FormatSize.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "B")
+	mult := int64(1)
+	if str != "" {
+		switch str[len(str)-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		case 'T':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			str = str[:len(str)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	// float64(math.MaxInt64) rounds up to 2^63, which no int64 holds.
+	size := n * float64(mult)
+	if size >= float64(math.MaxInt64) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(size), nil
+}
+
+// ParseAge parses durations like "90m", "36h", "30d" or "2w". Days and
+// weeks are whole numbers; anything else goes through time.ParseDuration.
+func ParseAge(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if num, ok := strings.CutSuffix(str, suffix); ok {
+			n, err := strconv.Atoi(num)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+var timeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime parses an RFC 3339 timestamp, a local date or date and time
+// such as "2026-02-18" or "2026-02-18 10:30", or an age like "7d" meaning
+// that long before now.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	str := strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := ParseAge(str); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want e.g. 2026-02-18, \"2026-02-18 10:30\" or 7d)", s)
+}
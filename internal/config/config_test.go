@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Empty(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("want defaults, got %+v", cfg)
+	}
+}
+
+func TestParse_Backend(t *testing.T) {
+	cfg, err := Parse(strings.NewReader("backend = freedesktop\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Backend != BackendFreedesktop {
+		t.Errorf("Backend: want %q, got %q", BackendFreedesktop, cfg.Backend)
+	}
+}
+
+func TestParse_CommentsAndBlankLines(t *testing.T) {
+	input := "# storage\n\n   # indented comment\nbackend=toss\n"
+	cfg, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.Backend != BackendToss {
+		t.Errorf("Backend: want %q, got %q", BackendToss, cfg.Backend)
+	}
+}
+
+func TestParse_UnknownBackend(t *testing.T) {
+	if _, err := Parse(strings.NewReader("backend = recycle\n")); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestParse_UnknownKey(t *testing.T) {
+	_, err := Parse(strings.NewReader("\ncolour = blue\n"))
+	if err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error should mention line number, got %v", err)
+	}
+}
+
+func TestParse_MissingEquals(t *testing.T) {
+	if _, err := Parse(strings.NewReader("backend freedesktop\n")); err == nil {
+		t.Error("expected error for line without '='")
+	}
+}
+
+func TestParse_Retention(t *testing.T) {
+	input := "max_age = 30d\nmax_size = 2GB\nmax_items = 500\nauto_gc = true\n"
+	cfg, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Retention{MaxAge: 30 * 24 * time.Hour, MaxSize: 2 << 30, MaxItems: 500}
+	if cfg.Retention != want {
+		t.Errorf("Retention: want %+v, got %+v", want, cfg.Retention)
+	}
+	if !cfg.AutoGC {
+		t.Error("AutoGC: want true")
+	}
+}
+
+func TestParse_InvalidRetention(t *testing.T) {
+	for _, line := range []string{"max_age = soon", "max_size = lots", "max_items = -1", "auto_gc = maybe"} {
+		if _, err := Parse(strings.NewReader(line)); err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+}
+
+func TestParse_Protect(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	input := "protect = /data\nprotect = ~/work/\nprotect_defaults = false\n"
+	cfg, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := []string{"/data", filepath.Join(home, "work")}; !slices.Equal(cfg.Protect, want) {
+		t.Errorf("Protect: want %q, got %q", want, cfg.Protect)
+	}
+	if cfg.ProtectDefaults {
+		t.Error("ProtectDefaults: want false")
+	}
+	for _, line := range []string{"protect = data", "protect_defaults = sometimes"} {
+		if _, err := Parse(strings.NewReader(line)); err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+}
+
+func TestParse_Confirm(t *testing.T) {
+	cfg, err := Parse(strings.NewReader("confirm_args = 10\nconfirm_size = 2GB\nconfirm_files = 0\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := (Thresholds{Args: 10, Size: 2 << 30}); cfg.Confirm != want {
+		t.Errorf("Confirm: want %+v, got %+v", want, cfg.Confirm)
+	}
+	for _, line := range []string{"confirm_args = -1", "confirm_size = lots", "confirm_files = many"} {
+		if _, err := Parse(strings.NewReader(line)); err == nil {
+			t.Errorf("%q: expected error", line)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1K", 1024},
+		{"1.5KB", 1536},
+		{"10mb", 10 << 20},
+		{"2G", 2 << 30},
+		{" 1TB ", 1 << 40},
+		{"8388607T", 8388607 << 40},
+	}
+	for _, c := range cases {
+		got, err := ParseSize(c.input)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", c.input, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseSize(%q): want %d, got %d", c.input, c.want, got)
+		}
+	}
+	for _, bad := range []string{"", "GB", "-1K", "ten", "inf", "1e19", "9999999T", "8388608T", "9223372036854775807"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q): expected error", bad)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"90m", 90 * time.Minute},
+		{"36h", 36 * time.Hour},
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+	}
+	for _, c := range cases {
+		got, err := ParseAge(c.input)
+		if err != nil {
+			t.Errorf("ParseAge(%q): %v", c.input, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseAge(%q): want %v, got %v", c.input, c.want, got)
+		}
+	}
+	for _, bad := range []string{"", "d", "1.5d", "-2h", "soon"} {
+		if _, err := ParseAge(bad); err == nil {
+			t.Errorf("ParseAge(%q): expected error", bad)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2026-02-18T10:30:00Z", time.Date(2026, 2, 18, 10, 30, 0, 0, time.UTC)},
+		{"2026-02-18", time.Date(2026, 2, 18, 0, 0, 0, 0, time.Local)},
+		{"2026-02-18 10:30", time.Date(2026, 2, 18, 10, 30, 0, 0, time.Local)},
+		{"2026-02-18T10:30:15", time.Date(2026, 2, 18, 10, 30, 15, 0, time.Local)},
+		{"7d", now.Add(-7 * 24 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+	}
+	for _, c := range cases {
+		got, err := ParseTime(c.input, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", c.input, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("ParseTime(%q): want %v, got %v", c.input, c.want, got)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "2026-13-01"} {
+		if _, err := ParseTime(bad, now); err == nil {
+			t.Errorf("ParseTime(%q): expected error", bad)
+		}
+	}
+}
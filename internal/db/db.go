@@ -3,7 +3,10 @@ package db
 import (
 	"crypto/rand"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,39 +16,111 @@ import (
 )
 
 type Entry struct {
-	ID           string
-	OriginalPath string
-	BinName      string
-	TossedAt     time.Time
-	IsDir        bool
-	SizeBytes    int64
-}
-
-const schema = `
-CREATE TABLE IF NOT EXISTS entries (
-	id           TEXT PRIMARY KEY,
-	original_path TEXT NOT NULL,
-	bin_name      TEXT NOT NULL,
-	tossed_at     DATETIME NOT NULL,
-	is_dir        INTEGER NOT NULL,
-	size_bytes    INTEGER NOT NULL
-);`
+	ID           string    `json:"id"`
+	OriginalPath string    `json:"original_path"`
+	BinName      string    `json:"bin_name"`
+	BinDir       string    `json:"bin_dir"`
+	TossedAt     time.Time `json:"tossed_at"`
+	IsDir        bool      `json:"is_dir"`
+	SizeBytes    int64     `json:"size_bytes"`
+
+	// The item's own metadata when it was tossed. Entries recorded before
+	// it was kept have a zero ModTime.
+	Mode       fs.FileMode `json:"mode"`
+	UID        int         `json:"uid"`
+	GID        int         `json:"gid"`
+	ModTime    time.Time   `json:"mtime"`
+	LinkTarget string      `json:"link_target"`
+
+	// Where and by whom it was tossed; empty when not known.
+	Hostname string `json:"hostname"`
+	Cwd      string `json:"cwd"`
+	Command  string `json:"command"`
+	User     string `json:"user"`
+
+	// Batch is shared by the items tossed in one run of toss; empty for
+	// items tossed before it was recorded or by other programs.
+	Batch string `json:"batch"`
+}
+
+const entryColumns = `id, original_path, bin_name, bin_dir, tossed_at, is_dir, size_bytes,
+	mode, uid, gid, mtime, link_target, hostname, cwd, command, user, batch`
 
 func Open(path string) (*sql.DB, error) {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return nil, fmt.Errorf("creating db dir: %w", err)
 	}
-	d, err := sql.Open("sqlite", path)
+	d, err := sql.Open("sqlite", dsn(path))
 	if err != nil {
 		return nil, fmt.Errorf("opening db: %w", err)
 	}
-	if _, err := d.Exec(schema); err != nil {
+	if err := migrate(d, path); err != nil {
 		d.Close()
-		return nil, fmt.Errorf("initializing schema: %w", err)
+		return nil, err
 	}
 	return d, nil
 }
 
+// OpenReadOnly opens the index at path for reading only, leaving every
+// file as it is, for dry runs. A missing index opens as an empty one in
+// memory. An index that needs migrating is refused, as migrating writes.
+func OpenReadOnly(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		d, err := sql.Open("sqlite", "file::memory:")
+		if err != nil {
+			return nil, fmt.Errorf("opening db: %w", err)
+		}
+		// Each connection would get a database of its own.
+		d.SetMaxOpenConns(1)
+		if err := migrateFrom(d, 0); err != nil {
+			d.Close()
+			return nil, err
+		}
+		return d, nil
+	}
+
+	// Without a write-ahead log no other toss has the index open, and
+	// immutable keeps SQLite from creating one, and its -shm file, just to
+	// read. With one, it has to be read too.
+	params := "immutable=1"
+	if _, err := os.Stat(path + "-wal"); err == nil {
+		params = "mode=ro&_pragma=busy_timeout(10000)"
+	}
+	d, err := sql.Open("sqlite", "file:"+(&url.URL{Path: path}).EscapedPath()+"?"+params)
+	if err != nil {
+		return nil, fmt.Errorf("opening db: %w", err)
+	}
+	version, err := userVersion(d)
+	if err != nil {
+		d.Close()
+		return nil, fmt.Errorf("reading schema version: %w", err)
+	}
+	if version > schemaVersion {
+		d.Close()
+		return nil, fmt.Errorf("database schema v%d is newer than this toss supports (v%d)", version, schemaVersion)
+	}
+	if version < schemaVersion {
+		d.Close()
+		return nil, fmt.Errorf("database schema is v%d and this toss needs v%d; run it once without --dry-run to upgrade", version, schemaVersion)
+	}
+	return d, nil
+}
+
+// dsn configures every connection for use by many toss processes at once:
+// WAL so readers never block the writer, a busy timeout instead of failing
+// at once on a locked database, and write transactions that take the write
+// lock when they begin rather than failing half way through.
+func dsn(path string) string {
+	return "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+}
+
+// Execer is satisfied by both *sql.DB and *sql.Tx, so index changes can be
+// made on their own or as part of a journal commit.
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 func NewID() string {
 	b := make([]byte, 16)
 	rand.Read(b)
@@ -54,22 +129,67 @@ func NewID() string {
 	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
 }
 
-func Append(d *sql.DB, e Entry) error {
+func Append(d Execer, e Entry) error {
+	if e.Batch != "" {
+		_, err := d.Exec(
+			`INSERT OR IGNORE INTO batches (id, tossed_at, command) VALUES (?, ?, ?)`,
+			e.Batch, e.TossedAt.UTC().Format(time.RFC3339), e.Command,
+		)
+		if err != nil {
+			return err
+		}
+	}
 	_, err := d.Exec(
-		`INSERT INTO entries (id, original_path, bin_name, tossed_at, is_dir, size_bytes)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		e.ID, e.OriginalPath, e.BinName, e.TossedAt.UTC().Format(time.RFC3339), boolToInt(e.IsDir), e.SizeBytes,
+		`INSERT INTO entries (`+entryColumns+`)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.ID, e.OriginalPath, e.BinName, e.BinDir, e.TossedAt.UTC().Format(time.RFC3339), boolToInt(e.IsDir), e.SizeBytes,
+		uint32(e.Mode), e.UID, e.GID, formatModTime(e.ModTime), e.LinkTarget, e.Hostname, e.Cwd, e.Command, e.User, e.Batch,
 	)
 	return err
 }
 
-func Remove(d *sql.DB, id string) error {
+func Remove(d Execer, id string) error {
 	_, err := d.Exec(`DELETE FROM entries WHERE id = ?`, id)
 	return err
 }
 
+// Clear removes every entry, and the runs they were tossed in.
+func Clear(d Execer) error {
+	_, err := d.Exec(`DELETE FROM entries; DELETE FROM batches`)
+	return err
+}
+
+// Verify runs SQLite's quick integrity check.
+func Verify(d *sql.DB) error {
+	var result string
+	if err := d.QueryRow(`PRAGMA quick_check`).Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
 func All(d *sql.DB) ([]Entry, error) {
-	rows, err := d.Query(`SELECT id, original_path, bin_name, tossed_at, is_dir, size_bytes FROM entries ORDER BY tossed_at`)
+	return List(d, Filter{})
+}
+
+// IsEmpty reports whether the index has no entries at all.
+func IsEmpty(d *sql.DB) (bool, error) {
+	var empty bool
+	err := d.QueryRow(`SELECT NOT EXISTS (SELECT 1 FROM entries)`).Scan(&empty)
+	return empty, err
+}
+
+// FindByIDPrefix returns the entries whose ID starts with prefix, so a
+// unique prefix works like a git short hash.
+func FindByIDPrefix(d *sql.DB, prefix string) ([]Entry, error) {
+	prefix = strings.ToLower(prefix)
+	rows, err := d.Query(
+		`SELECT `+entryColumns+` FROM entries WHERE substr(id, 1, ?) = ? ORDER BY tossed_at, rowid`,
+		len(prefix), prefix,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -77,13 +197,41 @@ func All(d *sql.DB) ([]Entry, error) {
 	return scanEntries(rows)
 }
 
+// ShortID abbreviates an ID for display.
+func ShortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// BinDirs returns every bin directory recorded on an entry.
+func BinDirs(d *sql.DB) ([]string, error) {
+	rows, err := d.Query(`SELECT DISTINCT bin_dir FROM entries WHERE bin_dir != '' ORDER BY bin_dir`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var dirs []string
+	for rows.Next() {
+		var dir string
+		if err := rows.Scan(&dir); err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, rows.Err()
+}
+
 func FindByQuery(d *sql.DB, query string) ([]Entry, error) {
-	lower := "%" + strings.ToLower(query) + "%"
+	return List(d, Filter{Contains: query})
+}
+
+// FindByPath returns every entry tossed from exactly path, oldest first.
+func FindByPath(d *sql.DB, path string) ([]Entry, error) {
 	rows, err := d.Query(
-		`SELECT id, original_path, bin_name, tossed_at, is_dir, size_bytes FROM entries
-		 WHERE LOWER(original_path) LIKE ? OR LOWER(bin_name) LIKE ?
-		 ORDER BY tossed_at`,
-		lower, lower,
+		`SELECT `+entryColumns+` FROM entries WHERE original_path = ? ORDER BY tossed_at, rowid`,
+		path,
 	)
 	if err != nil {
 		return nil, err
@@ -92,27 +240,133 @@ func FindByQuery(d *sql.DB, query string) ([]Entry, error) {
 	return scanEntries(rows)
 }
 
-func scanEntries(rows *sql.Rows) ([]Entry, error) {
-	var entries []Entry
+// A Batch is one run of toss that tossed something. It is kept after its
+// items leave the bin.
+type Batch struct {
+	ID       string
+	TossedAt time.Time
+	Command  string
+}
+
+// LastBatches returns the n batches most recently tossed, newest first,
+// whether or not any of their items are left.
+func LastBatches(d *sql.DB, n int) ([]Batch, error) {
+	rows, err := d.Query(
+		`SELECT id, tossed_at, command FROM batches ORDER BY tossed_at DESC, seq DESC LIMIT ?`,
+		n,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batches []Batch
 	for rows.Next() {
-		var e Entry
-		var tossedStr string
-		var isDir int
-		if err := rows.Scan(&e.ID, &e.OriginalPath, &e.BinName, &tossedStr, &isDir, &e.SizeBytes); err != nil {
+		var b Batch
+		var tossedAt string
+		if err := rows.Scan(&b.ID, &tossedAt, &b.Command); err != nil {
 			return nil, err
 		}
-		t, err := time.Parse(time.RFC3339, tossedStr)
+		// A time fsck would flag is left zero rather than hiding the run.
+		if t, err := time.Parse(time.RFC3339, tossedAt); err == nil {
+			b.TossedAt = t.Local()
+		}
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+// FindByBatch returns the entries tossed in batch, in the order tossed.
+func FindByBatch(d *sql.DB, batch string) ([]Entry, error) {
+	rows, err := d.Query(
+		`SELECT `+entryColumns+` FROM entries WHERE batch = ? ORDER BY tossed_at, rowid`,
+		batch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntries(rows)
+}
+
+// AllChecked is like All, but rows whose tossed_at cannot be parsed are
+// returned separately, with a zero TossedAt, instead of failing the whole
+// listing.
+func AllChecked(d *sql.DB) (entries, badTime []Entry, err error) {
+	rows, err := d.Query(`SELECT ` + entryColumns + ` FROM entries ORDER BY tossed_at, rowid`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if errors.Is(err, errBadTime) {
+			badTime = append(badTime, e)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, badTime, rows.Err()
+}
+
+func SetSize(d Execer, id string, size int64) error {
+	_, err := d.Exec(`UPDATE entries SET size_bytes = ? WHERE id = ?`, size, id)
+	return err
+}
+
+func SetTossedAt(d Execer, id string, t time.Time) error {
+	_, err := d.Exec(`UPDATE entries SET tossed_at = ? WHERE id = ?`, t.UTC().Format(time.RFC3339), id)
+	return err
+}
+
+var errBadTime = errors.New("parsing tossed_at")
+
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	var entries []Entry
+	for rows.Next() {
+		e, err := scanEntry(rows)
 		if err != nil {
-			return nil, fmt.Errorf("parsing tossed_at: %w", err)
+			return nil, err
 		}
-		e.TossedAt = t.Local()
-		e.IsDir = isDir != 0
-		e.BinName = filepath.Base(e.BinName) // sanitize just in case
 		entries = append(entries, e)
 	}
 	return entries, rows.Err()
 }
 
+func scanEntry(rows *sql.Rows) (Entry, error) {
+	var e Entry
+	var tossedStr, mtimeStr string
+	var isDir int
+	var mode uint32
+	if err := rows.Scan(&e.ID, &e.OriginalPath, &e.BinName, &e.BinDir, &tossedStr, &isDir, &e.SizeBytes,
+		&mode, &e.UID, &e.GID, &mtimeStr, &e.LinkTarget, &e.Hostname, &e.Cwd, &e.Command, &e.User, &e.Batch); err != nil {
+		return Entry{}, err
+	}
+	e.IsDir = isDir != 0
+	e.Mode = fs.FileMode(mode)
+	if mtime, err := time.Parse(time.RFC3339Nano, mtimeStr); err == nil {
+		e.ModTime = mtime.Local()
+	}
+	e.BinName = filepath.Base(e.BinName) // sanitize just in case
+	t, err := time.Parse(time.RFC3339, tossedStr)
+	if err != nil {
+		return e, fmt.Errorf("%w: %w", errBadTime, err)
+	}
+	e.TossedAt = t.Local()
+	return e, nil
+}
+
+// formatModTime keeps sub-second precision, unlike tossed_at, so the
+// original mtime can be put back exactly. A zero time is stored empty.
+func formatModTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
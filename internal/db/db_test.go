@@ -294,3 +294,306 @@ func TestFindByQuery_MultipleMatches(t *testing.T) {
 		t.Errorf("want 2 results for 'report', got %d", len(results))
 	}
 }
+
+func TestFindByPath_ExactOldestFirst(t *testing.T) {
+	d := openTestDB(t)
+	base := time.Now().Truncate(time.Second)
+	for i, path := range []string{"/etc/app.conf", "/etc/app.conf.bak", "/etc/app.conf"} {
+		e := makeEntry(NewID(), path, "bin")
+		e.TossedAt = base.Add(time.Duration(-i) * time.Hour)
+		if err := Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	entries, err := FindByPath(d, "/etc/app.conf")
+	if err != nil {
+		t.Fatalf("FindByPath: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %d", len(entries))
+	}
+	if !entries[0].TossedAt.Before(entries[1].TossedAt) {
+		t.Errorf("want oldest first, got %v then %v", entries[0].TossedAt, entries[1].TossedAt)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	d := openTestDB(t)
+	if empty, err := IsEmpty(d); err != nil || !empty {
+		t.Errorf("IsEmpty on a new index: want true, got %v (%v)", empty, err)
+	}
+	if err := Append(d, makeEntry(NewID(), "/a", "bin")); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if empty, err := IsEmpty(d); err != nil || empty {
+		t.Errorf("IsEmpty with an entry: want false, got %v (%v)", empty, err)
+	}
+}
+
+func TestLastBatchesAndFindByBatch(t *testing.T) {
+	d := openTestDB(t)
+	base := time.Now().Truncate(time.Second)
+	// Two runs in the same second, an older one, and an item without a batch.
+	for i, b := range []struct{ batch, path string }{
+		{"old", "/a"}, {"old", "/b"}, {"", "/c"}, {"mid", "/d"}, {"new", "/e"}, {"new", "/f"},
+	} {
+		e := makeEntry(NewID(), b.path, "bin")
+		e.Batch = b.batch
+		if i == 0 || i == 1 {
+			e.TossedAt = base.Add(-time.Hour)
+		} else {
+			e.TossedAt = base
+		}
+		if err := Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	batches, err := LastBatches(d, 5)
+	if err != nil {
+		t.Fatalf("LastBatches: %v", err)
+	}
+	var ids []string
+	for _, b := range batches {
+		ids = append(ids, b.ID)
+	}
+	if strings.Join(ids, ",") != "new,mid,old" {
+		t.Errorf("LastBatches: want new,mid,old, got %v", ids)
+	}
+	if batches, _ := LastBatches(d, 1); len(batches) != 1 || batches[0].ID != "new" {
+		t.Errorf("LastBatches(1): want [new], got %v", batches)
+	}
+
+	entries, err := FindByBatch(d, "new")
+	if err != nil {
+		t.Fatalf("FindByBatch: %v", err)
+	}
+	if len(entries) != 2 || entries[0].OriginalPath != "/e" || entries[1].OriginalPath != "/f" || entries[0].Batch != "new" {
+		t.Errorf("FindByBatch: got %+v", entries)
+	}
+}
+
+func TestLastBatches_OutliveTheirItems(t *testing.T) {
+	d := openTestDB(t)
+	base := time.Now().Truncate(time.Second)
+	for i, batch := range []string{"old", "new"} {
+		e := makeEntry(NewID(), "/"+batch, "bin")
+		e.Batch, e.Command, e.TossedAt = batch, "rm -r", base.Add(time.Duration(i)*time.Minute)
+		if err := Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		if batch == "new" {
+			if err := Remove(d, e.ID); err != nil {
+				t.Fatalf("Remove: %v", err)
+			}
+		}
+	}
+
+	batches, err := LastBatches(d, 1)
+	if err != nil {
+		t.Fatalf("LastBatches: %v", err)
+	}
+	if len(batches) != 1 || batches[0].ID != "new" || batches[0].Command != "rm -r" || !batches[0].TossedAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("LastBatches: want the emptied run new, got %+v", batches)
+	}
+	if entries, _ := FindByBatch(d, "new"); len(entries) != 0 {
+		t.Errorf("FindByBatch: want nothing left, got %d", len(entries))
+	}
+
+	if err := Clear(d); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if batches, _ := LastBatches(d, 5); len(batches) != 0 {
+		t.Errorf("want no runs after Clear, got %+v", batches)
+	}
+}
+
+func TestAppendAndAll_BinDir(t *testing.T) {
+	d := openTestDB(t)
+	e := makeEntry(NewID(), "/mnt/data/big.iso", "id-big.iso")
+	e.BinDir = "/mnt/data/.toss-1000/files"
+	if err := Append(d, e); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	entries, err := All(d)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(entries) != 1 || entries[0].BinDir != e.BinDir {
+		t.Errorf("BinDir: want %q, got %+v", e.BinDir, entries)
+	}
+}
+
+func TestAppendAndAll_Metadata(t *testing.T) {
+	d := openTestDB(t)
+	e := makeEntry(NewID(), "/home/u/run.sh", "id-run.sh")
+	e.Mode = 0755
+	e.UID, e.GID = 1000, 100
+	e.ModTime = time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	e.LinkTarget = "../bin/run"
+	e.Hostname, e.Cwd, e.Command, e.User = "laptop", "/home/u", "toss run.sh", "u"
+	if err := Append(d, e); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	entries, err := All(d)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("All: %v (%d entries)", err, len(entries))
+	}
+	got := entries[0]
+	if got.Mode != e.Mode || got.UID != e.UID || got.GID != e.GID || got.LinkTarget != e.LinkTarget {
+		t.Errorf("file metadata: want %+v, got %+v", e, got)
+	}
+	if !got.ModTime.Equal(e.ModTime) {
+		t.Errorf("ModTime: want %v, got %v", e.ModTime, got.ModTime)
+	}
+	if got.Hostname != e.Hostname || got.Cwd != e.Cwd || got.Command != e.Command || got.User != e.User {
+		t.Errorf("context: want %+v, got %+v", e, got)
+	}
+}
+
+func TestAppendAndAll_UnknownModTime(t *testing.T) {
+	d := openTestDB(t)
+	if err := Append(d, makeEntry(NewID(), "/a.txt", "id-a.txt")); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	entries, err := All(d)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("All: %v (%d entries)", err, len(entries))
+	}
+	if !entries[0].ModTime.IsZero() {
+		t.Errorf("ModTime: want zero, got %v", entries[0].ModTime)
+	}
+}
+
+func TestBinDirs_Distinct(t *testing.T) {
+	d := openTestDB(t)
+	e1 := makeEntry(NewID(), "/a.txt", "id1-a.txt")
+	e2 := makeEntry(NewID(), "/mnt/b.txt", "id2-b.txt")
+	e2.BinDir = "/mnt/.toss-1000/files"
+	e3 := makeEntry(NewID(), "/mnt/c.txt", "id3-c.txt")
+	e3.BinDir = "/mnt/.toss-1000/files"
+	for _, e := range []Entry{e1, e2, e3} {
+		if err := Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	dirs, err := BinDirs(d)
+	if err != nil {
+		t.Fatalf("BinDirs: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0] != "/mnt/.toss-1000/files" {
+		t.Errorf("want only the mount bin, got %v", dirs)
+	}
+}
+
+func TestOpen_AddsBinDirToOldSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	_, err = old.Exec(`CREATE TABLE entries (
+		id TEXT PRIMARY KEY, original_path TEXT NOT NULL, bin_name TEXT NOT NULL,
+		tossed_at DATETIME NOT NULL, is_dir INTEGER NOT NULL, size_bytes INTEGER NOT NULL);
+		INSERT INTO entries VALUES ('id1', '/old.txt', 'id1-old.txt', '2026-01-01T00:00:00Z', 0, 3);`)
+	old.Close()
+	if err != nil {
+		t.Fatalf("creating old schema: %v", err)
+	}
+
+	d, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+	entries, err := All(d)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(entries) != 1 || entries[0].BinDir != "" {
+		t.Errorf("want old entry with empty BinDir, got %+v", entries)
+	}
+}
+
+func TestFindByIDPrefix(t *testing.T) {
+	d := openTestDB(t)
+	e1 := makeEntry("3f2a0000-0000-4000-8000-000000000001", "/a.txt", "id1-a.txt")
+	e2 := makeEntry("3f2b0000-0000-4000-8000-000000000002", "/b.txt", "id2-b.txt")
+	for _, e := range []Entry{e1, e2} {
+		if err := Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	cases := []struct {
+		prefix string
+		want   int
+	}{
+		{e2.ID, 1},
+		{"3f2b", 1},
+		{"3F2B", 1},
+		{"3f2", 2},
+		{"ffff", 0},
+		{"3f2b0000-0000-4000-8000-0000000000020", 0},
+	}
+	for _, c := range cases {
+		results, err := FindByIDPrefix(d, c.prefix)
+		if err != nil {
+			t.Fatalf("FindByIDPrefix(%q): %v", c.prefix, err)
+		}
+		if len(results) != c.want {
+			t.Errorf("FindByIDPrefix(%q): want %d results, got %d", c.prefix, c.want, len(results))
+		}
+	}
+}
+
+func TestShortID(t *testing.T) {
+	if got := ShortID("3f2a1b4c-0000-4000-8000-000000000001"); got != "3f2a1b4c" {
+		t.Errorf("ShortID: want 3f2a1b4c, got %q", got)
+	}
+	if got := ShortID("abc"); got != "abc" {
+		t.Errorf("ShortID of short id: want abc, got %q", got)
+	}
+}
+
+func TestAllChecked_SeparatesBadTimes(t *testing.T) {
+	d := openTestDB(t)
+	for _, id := range []string{"good", "bad"} {
+		if err := Append(d, makeEntry(id, "/tmp/"+id, id+"-x")); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if _, err := d.Exec(`UPDATE entries SET tossed_at = 'garbage' WHERE id = 'bad'`); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if _, err := All(d); err == nil {
+		t.Error("All should fail on an unparseable tossed_at")
+	}
+	entries, badTime, err := AllChecked(d)
+	if err != nil {
+		t.Fatalf("AllChecked: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != "good" {
+		t.Errorf("entries: got %+v", entries)
+	}
+	if len(badTime) != 1 || badTime[0].ID != "bad" || badTime[0].OriginalPath != "/tmp/bad" {
+		t.Errorf("badTime: got %+v", badTime)
+	}
+}
+
+func TestOpen_ConfiguresForConcurrency(t *testing.T) {
+	d := openTestDB(t)
+	var mode string
+	if err := d.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatalf("journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode: want wal, got %q", mode)
+	}
+	var timeout int
+	if err := d.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+		t.Fatalf("busy_timeout: %v", err)
+	}
+	if timeout == 0 {
+		t.Error("busy_timeout should be set")
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/roman91DE/toss/internal/fuzzy"
+	"modernc.org/sqlite"
+)
+
+// SQL functions behind Filter.Regexp and Filter.Fuzzy, so that matching
+// happens while rows are scanned and only matches are read out.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, sqlRegexp)
+	sqlite.MustRegisterDeterministicScalarFunction("fuzzy_match", 2, sqlFuzzyMatch)
+}
+
+// regexps caches compiled patterns: a query passes the same one for every
+// row.
+var regexps sync.Map
+
+// sqlRegexp implements "text REGEXP pattern", which SQLite calls as
+// regexp(pattern, text).
+func sqlRegexp(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	pattern, text := textArg(args[0]), textArg(args[1])
+	re, ok := regexps.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		re, _ = regexps.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(text), nil
+}
+
+// sqlFuzzyMatch implements fuzzy_match(pattern, text).
+func sqlFuzzyMatch(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	return fuzzy.Matches(textArg(args[0]), textArg(args[1])), nil
+}
+
+func textArg(v driver.Value) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
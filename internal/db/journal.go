@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Journaled operation kinds.
+const (
+	OpToss    = "toss"
+	OpRestore = "restore"
+	OpMerge   = "merge"
+	OpDelete  = "delete"
+)
+
+const (
+	statePending   = "pending"
+	stateCommitted = "committed"
+)
+
+// Op is a journaled operation that was begun but never committed, i.e. one
+// that was interrupted between touching the filesystem and the index.
+type Op struct {
+	ID        int64
+	Kind      string
+	Entry     Entry
+	Dest      string
+	StartedAt time.Time
+}
+
+// Begin records, before anything is moved, that an operation on e is
+// about to start. dest is where a restore is headed.
+func Begin(d *sql.DB, kind string, e Entry, dest string) (int64, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	res, err := d.Exec(
+		`INSERT INTO journal (kind, state, entry, dest, started_at) VALUES (?, ?, ?, ?, ?)`,
+		kind, statePending, string(data), dest, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("writing journal: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Commit applies an operation's index change and marks it committed in a
+// single transaction, so the two cannot be separated by a crash.
+func Commit(d *sql.DB, id int64, apply func(tx *sql.Tx) error) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := apply(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE journal SET state = ? WHERE id = ?`, stateCommitted, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Abandon forgets an operation that was rolled back.
+func Abandon(d *sql.DB, id int64) error {
+	_, err := d.Exec(`DELETE FROM journal WHERE id = ?`, id)
+	return err
+}
+
+// Pending returns the operations that were never committed, oldest first.
+func Pending(d *sql.DB) ([]Op, error) {
+	rows, err := d.Query(
+		`SELECT id, kind, entry, dest, started_at FROM journal WHERE state = ? ORDER BY id`,
+		statePending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ops []Op
+	for rows.Next() {
+		var op Op
+		var data, started string
+		if err := rows.Scan(&op.ID, &op.Kind, &data, &op.Dest, &started); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &op.Entry); err != nil {
+			return nil, fmt.Errorf("journal entry %d: %w", op.ID, err)
+		}
+		t, err := time.Parse(time.RFC3339, started)
+		if err != nil {
+			return nil, fmt.Errorf("journal entry %d: parsing started_at: %w", op.ID, err)
+		}
+		op.StartedAt = t.Local()
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// PruneJournal drops committed operations.
+func PruneJournal(d *sql.DB) error {
+	_, err := d.Exec(`DELETE FROM journal WHERE state = ?`, stateCommitted)
+	return err
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestJournal_PendingUntilCommitted(t *testing.T) {
+	d := openTestDB(t)
+	e := makeEntry("id-1", "/tmp/a.txt", "id-1-a.txt")
+	op, err := Begin(d, OpToss, e, "")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+
+	ops, err := Pending(d)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if len(ops) != 1 || ops[0].ID != op || ops[0].Kind != OpToss {
+		t.Fatalf("want one pending toss, got %+v", ops)
+	}
+	if got := ops[0].Entry; got.ID != e.ID || got.OriginalPath != e.OriginalPath || !got.TossedAt.Equal(e.TossedAt) {
+		t.Errorf("journaled entry: want %+v, got %+v", e, got)
+	}
+
+	if err := Commit(d, op, func(tx *sql.Tx) error { return Append(tx, e) }); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if ops, _ := Pending(d); len(ops) != 0 {
+		t.Errorf("want no pending ops after Commit, got %d", len(ops))
+	}
+	if all, _ := All(d); len(all) != 1 {
+		t.Errorf("want entry recorded by Commit, got %d entries", len(all))
+	}
+}
+
+func TestJournal_FailedCommitStaysPending(t *testing.T) {
+	d := openTestDB(t)
+	e := makeEntry("id-1", "/tmp/a.txt", "id-1-a.txt")
+	op, err := Begin(d, OpToss, e, "")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	boom := errors.New("boom")
+	err = Commit(d, op, func(tx *sql.Tx) error {
+		if err := Append(tx, e); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("Commit: want boom, got %v", err)
+	}
+	if all, _ := All(d); len(all) != 0 {
+		t.Errorf("failed Commit must not leave the entry behind, got %d entries", len(all))
+	}
+	if ops, _ := Pending(d); len(ops) != 1 {
+		t.Errorf("want op still pending, got %d", len(ops))
+	}
+}
+
+func TestJournal_AbandonAndPrune(t *testing.T) {
+	d := openTestDB(t)
+	e := makeEntry("id-1", "/tmp/a.txt", "id-1-a.txt")
+	abandoned, _ := Begin(d, OpRestore, e, "/tmp/b.txt")
+	committed, _ := Begin(d, OpDelete, e, "")
+	if err := Abandon(d, abandoned); err != nil {
+		t.Fatalf("Abandon: %v", err)
+	}
+	if err := Commit(d, committed, func(tx *sql.Tx) error { return nil }); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := PruneJournal(d); err != nil {
+		t.Fatalf("PruneJournal: %v", err)
+	}
+	var n int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM journal`).Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("want empty journal, got %d rows", n)
+	}
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Filter narrows and orders the entries returned by List. Zero values
+// mean "no restriction".
+type Filter struct {
+	Since      time.Time
+	Until      time.Time
+	PathPrefix string
+	Glob       string // SQLite GLOB against the original path
+	Contains   string // case-insensitive substring of the path or bin name
+	Regexp     string // Go regular expression matched against the original path
+	Fuzzy      string // characters found in order in the original path, ignoring case
+	MinSize    int64
+	SizeBelow  int64 // exclusive, unlike MinSize
+	DirsOnly   bool
+	Sort       string // "time" (default), "size" or "path"
+	Reverse    bool
+	Limit      int
+}
+
+var sortColumns = map[string]string{
+	"":     "tossed_at",
+	"time": "tossed_at",
+	"size": "size_bytes",
+	"path": "original_path",
+}
+
+// List returns the entries matching f. Filtering, ordering and limiting
+// all happen in SQL so large bins stay fast.
+func List(d *sql.DB, f Filter) ([]Entry, error) {
+	column, ok := sortColumns[f.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort key %q (want time, size or path)", f.Sort)
+	}
+
+	var where []string
+	var args []any
+	if !f.Since.IsZero() {
+		where = append(where, "tossed_at >= ?")
+		args = append(args, f.Since.UTC().Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "tossed_at <= ?")
+		args = append(args, f.Until.UTC().Format(time.RFC3339))
+	}
+	if f.PathPrefix != "" {
+		// A range rather than LIKE: it is case-sensitive and can use the index.
+		where = append(where, "original_path >= ? AND original_path < ?")
+		args = append(args, f.PathPrefix, f.PathPrefix+"\U0010FFFF")
+	}
+	if f.Glob != "" {
+		where = append(where, "original_path GLOB ?")
+		args = append(args, f.Glob)
+	}
+	if f.Contains != "" {
+		if utf8.RuneCountInString(f.Contains) >= 3 {
+			// The trigram index finds any substring of three or more
+			// characters; quoting makes the query a single phrase.
+			where = append(where, "id IN (SELECT id FROM entries_fts WHERE entries_fts MATCH ?)")
+			args = append(args, `"`+strings.ReplaceAll(f.Contains, `"`, `""`)+`"`)
+		} else {
+			lower := "%" + strings.ToLower(f.Contains) + "%"
+			where = append(where, "(LOWER(original_path) LIKE ? OR LOWER(bin_name) LIKE ?)")
+			args = append(args, lower, lower)
+		}
+	}
+	if f.Regexp != "" {
+		where = append(where, "original_path REGEXP ?")
+		args = append(args, f.Regexp)
+	}
+	if f.Fuzzy != "" {
+		where = append(where, "fuzzy_match(?, original_path)")
+		args = append(args, f.Fuzzy)
+	}
+	if f.MinSize > 0 {
+		where = append(where, "size_bytes >= ?")
+		args = append(args, f.MinSize)
+	}
+	if f.SizeBelow > 0 {
+		where = append(where, "size_bytes < ?")
+		args = append(args, f.SizeBelow)
+	}
+	if f.DirsOnly {
+		where = append(where, "is_dir = 1")
+	}
+
+	query := `SELECT ` + entryColumns + ` FROM entries`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	dir := "ASC"
+	if f.Reverse {
+		dir = "DESC"
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, rowid %s`, column, dir, dir)
+	if f.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, f.Limit)
+	}
+
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEntries(rows)
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// seedList inserts four entries tossed one hour apart, oldest first.
+func seedList(t *testing.T) (*sql.DB, time.Time) {
+	t.Helper()
+	d := openTestDB(t)
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{ID: "a", OriginalPath: "/home/u/proj/main.go", BinName: "a-main.go", TossedAt: base, SizeBytes: 300},
+		{ID: "b", OriginalPath: "/home/u/proj/build", BinName: "b-build", TossedAt: base.Add(time.Hour), IsDir: true, SizeBytes: 5000},
+		{ID: "c", OriginalPath: "/home/u/Project/app.log", BinName: "c-app.log", TossedAt: base.Add(2 * time.Hour), SizeBytes: 100},
+		{ID: "d", OriginalPath: "/tmp/debug.log", BinName: "d-debug.log", TossedAt: base.Add(3 * time.Hour), SizeBytes: 200},
+	}
+	for _, e := range entries {
+		if err := Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	return d, base
+}
+
+func listIDs(t *testing.T, d *sql.DB, f Filter) string {
+	t.Helper()
+	entries, err := List(d, f)
+	if err != nil {
+		t.Fatalf("List(%+v): %v", f, err)
+	}
+	var s string
+	for _, e := range entries {
+		s += e.ID
+	}
+	return s
+}
+
+func TestList_NoFilter(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{}); got != "abcd" {
+		t.Errorf("want abcd, got %q", got)
+	}
+}
+
+func TestList_TimeRange(t *testing.T) {
+	d, base := seedList(t)
+	f := Filter{Since: base.Add(time.Hour), Until: base.Add(2 * time.Hour)}
+	if got := listIDs(t, d, f); got != "bc" {
+		t.Errorf("want bc, got %q", got)
+	}
+}
+
+func TestList_PathPrefixIsCaseSensitive(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{PathPrefix: "/home/u/proj"}); got != "ab" {
+		t.Errorf("want ab, got %q", got)
+	}
+}
+
+func TestList_Glob(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{Glob: "*.log"}); got != "cd" {
+		t.Errorf("want cd, got %q", got)
+	}
+}
+
+func TestList_MinSizeAndDirsOnly(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{MinSize: 200}); got != "abd" {
+		t.Errorf("MinSize: want abd, got %q", got)
+	}
+	if got := listIDs(t, d, Filter{DirsOnly: true}); got != "b" {
+		t.Errorf("DirsOnly: want b, got %q", got)
+	}
+}
+
+func TestList_Contains(t *testing.T) {
+	d, _ := seedList(t)
+	cases := map[string]string{
+		"PROJ":     "abc", // case-insensitive, through the index
+		"g.l":      "d",   // punctuation is matched literally
+		"d-deb":    "d",   // the bin name counts too
+		"go":       "a",   // too short for the index
+		`app"x`:    "",
+		"tmp/debu": "d",
+	}
+	for q, want := range cases {
+		if got := listIDs(t, d, Filter{Contains: q}); got != want {
+			t.Errorf("Contains %q: want %q, got %q", q, want, got)
+		}
+	}
+}
+
+func TestList_ContainsFollowsChanges(t *testing.T) {
+	d, _ := seedList(t)
+	if err := Remove(d, "d"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Exec(`UPDATE entries SET original_path = '/srv/debug.txt' WHERE id = 'c'`); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, d, Filter{Contains: "debug"}); got != "c" {
+		t.Errorf("want c, got %q", got)
+	}
+	if got := listIDs(t, d, Filter{Contains: "Project"}); got != "" {
+		t.Errorf("want nothing for the old path, got %q", got)
+	}
+}
+
+func TestList_RegexpAndFuzzy(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{Regexp: `/proj/|\.log$`}); got != "abcd" {
+		t.Errorf("Regexp: want abcd, got %q", got)
+	}
+	if got := listIDs(t, d, Filter{Regexp: `^/home/u/p`}); got != "ab" {
+		t.Errorf("Regexp is case-sensitive: want ab, got %q", got)
+	}
+	if got := listIDs(t, d, Filter{Fuzzy: "PJLG"}); got != "c" {
+		t.Errorf("Fuzzy: want c, got %q", got)
+	}
+	if _, err := List(d, Filter{Regexp: "("}); err == nil {
+		t.Error("invalid Regexp: want an error")
+	}
+}
+
+func TestList_SizeBelow(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{MinSize: 150, SizeBelow: 301}); got != "ad" {
+		t.Errorf("want ad, got %q", got)
+	}
+}
+
+func TestList_SortAndReverse(t *testing.T) {
+	d, _ := seedList(t)
+	cases := []struct {
+		f    Filter
+		want string
+	}{
+		{Filter{Sort: "size"}, "cdab"},
+		{Filter{Sort: "size", Reverse: true}, "badc"},
+		{Filter{Sort: "path"}, "cbad"},
+		{Filter{Sort: "time", Reverse: true}, "dcba"},
+	}
+	for _, c := range cases {
+		if got := listIDs(t, d, c.f); got != c.want {
+			t.Errorf("%+v: want %q, got %q", c.f, c.want, got)
+		}
+	}
+}
+
+func TestList_Limit(t *testing.T) {
+	d, _ := seedList(t)
+	if got := listIDs(t, d, Filter{Reverse: true, Limit: 2}); got != "dc" {
+		t.Errorf("want the 2 newest (dc), got %q", got)
+	}
+}
+
+func TestList_UnknownSort(t *testing.T) {
+	d, _ := seedList(t)
+	if _, err := List(d, Filter{Sort: "colour"}); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
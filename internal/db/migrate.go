@@ -0,0 +1,218 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/roman91DE/toss/internal/lock"
+)
+
+// migrations upgrade the schema one version at a time; the schema version
+// is kept in PRAGMA user_version. Databases written before versioning all
+// report version 0 whatever their shape, so the first steps are written to
+// be harmless on a database that already has their change. Steps added from
+// now on can assume the previous version exactly. Never edit or reorder an
+// existing step; append a new one.
+var migrations = []func(tx *sql.Tx) error{
+	// 1: the original entries table.
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS entries (
+			id            TEXT PRIMARY KEY,
+			original_path TEXT NOT NULL,
+			bin_name      TEXT NOT NULL,
+			tossed_at     DATETIME NOT NULL,
+			is_dir        INTEGER NOT NULL,
+			size_bytes    INTEGER NOT NULL
+		)`)
+		return err
+	},
+	// 2: the bin holding each item, for per-filesystem bins.
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "entries", "bin_dir", `TEXT NOT NULL DEFAULT ''`)
+	},
+	// 3: indexes for filtering and sorting in SQL.
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE INDEX IF NOT EXISTS entries_tossed_at ON entries (tossed_at);
+			CREATE INDEX IF NOT EXISTS entries_original_path ON entries (original_path);
+			CREATE INDEX IF NOT EXISTS entries_size_bytes ON entries (size_bytes);`)
+		return err
+	},
+	// 4: the operation journal.
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS journal (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind       TEXT NOT NULL,
+			state      TEXT NOT NULL,
+			entry      TEXT NOT NULL,
+			dest       TEXT NOT NULL DEFAULT '',
+			started_at DATETIME NOT NULL
+		)`)
+		return err
+	},
+	// 5: the item's metadata and the context it was tossed in.
+	func(tx *sql.Tx) error {
+		columns := []struct{ name, decl string }{
+			{"mode", `INTEGER NOT NULL DEFAULT 0`},
+			{"uid", `INTEGER NOT NULL DEFAULT 0`},
+			{"gid", `INTEGER NOT NULL DEFAULT 0`},
+			{"mtime", `TEXT NOT NULL DEFAULT ''`},
+			{"link_target", `TEXT NOT NULL DEFAULT ''`},
+			{"hostname", `TEXT NOT NULL DEFAULT ''`},
+			{"cwd", `TEXT NOT NULL DEFAULT ''`},
+			{"command", `TEXT NOT NULL DEFAULT ''`},
+			{"user", `TEXT NOT NULL DEFAULT ''`},
+		}
+		for _, c := range columns {
+			if _, err := tx.Exec(`ALTER TABLE entries ADD COLUMN ` + c.name + ` ` + c.decl); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 6: a trigram index over paths, so substring searches of large bins
+	// don't scan every row. Triggers keep it in step with entries.
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE VIRTUAL TABLE entries_fts USING fts5(
+				id UNINDEXED, original_path, bin_name, tokenize = 'trigram'
+			);
+			INSERT INTO entries_fts (id, original_path, bin_name)
+				SELECT id, original_path, bin_name FROM entries;
+			CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+				INSERT INTO entries_fts (id, original_path, bin_name)
+					VALUES (new.id, new.original_path, new.bin_name);
+			END;
+			CREATE TRIGGER entries_fts_delete AFTER DELETE ON entries BEGIN
+				DELETE FROM entries_fts WHERE id = old.id;
+			END;
+			CREATE TRIGGER entries_fts_update AFTER UPDATE OF id, original_path, bin_name ON entries BEGIN
+				UPDATE entries_fts SET id = new.id, original_path = new.original_path, bin_name = new.bin_name
+					WHERE id = old.id;
+			END;`)
+		return err
+	},
+	// 7: the toss run each item was tossed in, for undo.
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			ALTER TABLE entries ADD COLUMN batch TEXT NOT NULL DEFAULT '';
+			CREATE INDEX entries_batch ON entries (batch);`)
+		return err
+	},
+	// 8: the toss runs themselves, which outlive their items, so that undo
+	// can tell a run already undone from one that never happened.
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE batches (
+				seq       INTEGER PRIMARY KEY AUTOINCREMENT,
+				id        TEXT NOT NULL UNIQUE,
+				tossed_at DATETIME NOT NULL,
+				command   TEXT NOT NULL DEFAULT ''
+			);
+			CREATE INDEX batches_tossed_at ON batches (tossed_at);
+			INSERT INTO batches (id, tossed_at, command)
+				SELECT batch, MIN(tossed_at), MIN(command) FROM entries WHERE batch != ''
+				GROUP BY batch ORDER BY MAX(tossed_at), MAX(rowid);`)
+		return err
+	},
+}
+
+// schemaVersion is the version Open migrates databases to.
+var schemaVersion = len(migrations)
+
+// migrate brings d up to schemaVersion, holding the exclusive lock on
+// <path>.lock. Each step runs in its own transaction together with the
+// version bump. An existing database is
+// backed up to <path>.v<old version>.bak before the first step.
+func migrate(d *sql.DB, path string) error {
+	version, err := userVersion(d)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("database schema v%d is newer than this toss supports (v%d)", version, schemaVersion)
+	}
+	if version == schemaVersion {
+		return nil
+	}
+
+	// Only one process migrates; the others wait and find it done.
+	l, err := lock.Open(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if err := l.Exclusive(); err != nil {
+		return fmt.Errorf("locking db: %w", err)
+	}
+	if version, err = userVersion(d); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if version == schemaVersion {
+		return nil
+	}
+
+	if populated, err := hasTables(d); err != nil {
+		return err
+	} else if populated {
+		backup := fmt.Sprintf("%s.v%d.bak", path, version)
+		if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("backing up db: %w", err)
+		}
+		if _, err := d.Exec(`VACUUM INTO ?`, backup); err != nil {
+			return fmt.Errorf("backing up db: %w", err)
+		}
+	}
+
+	return migrateFrom(d, version)
+}
+
+// migrateFrom runs the steps after version.
+func migrateFrom(d *sql.DB, version int) error {
+	for v := version; v < schemaVersion; v++ {
+		if err := migrateStep(d, v+1, migrations[v]); err != nil {
+			return fmt.Errorf("migrating schema to v%d: %w", v+1, err)
+		}
+	}
+	return nil
+}
+
+func migrateStep(d *sql.DB, version int, step func(tx *sql.Tx) error) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := step(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func userVersion(d *sql.DB) (int, error) {
+	var v int
+	err := d.QueryRow(`PRAGMA user_version`).Scan(&v)
+	return v, err
+}
+
+func hasTables(d *sql.DB) (bool, error) {
+	var n int
+	err := d.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&n)
+	return n > 0, err
+}
+
+// addColumn adds a column unless a database from before versioning already
+// has it.
+func addColumn(tx *sql.Tx, table, column, decl string) error {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, decl))
+	return err
+}
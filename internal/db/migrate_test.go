@@ -0,0 +1,203 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadFixture creates a database from one of the historical schemas in
+// testdata.
+func loadFixture(t *testing.T, name string) string {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "toss.db")
+	d, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer d.Close()
+	if _, err := d.Exec(string(script)); err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+	return path
+}
+
+// schemaOf describes a database's tables, indexes and columns.
+func schemaOf(t *testing.T, d *sql.DB) []string {
+	t.Helper()
+	rows, err := d.Query(`SELECT type, name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		t.Fatalf("reading schema: %v", err)
+	}
+	var objects, tables []string
+	for rows.Next() {
+		var typ, name string
+		if err := rows.Scan(&typ, &name); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		objects = append(objects, typ+" "+name)
+		if typ == "table" {
+			tables = append(tables, name)
+		}
+	}
+	rows.Close()
+	for _, table := range tables {
+		var cols []string
+		crows, err := d.Query(`SELECT name, type, "notnull" FROM pragma_table_info(?)`, table)
+		if err != nil {
+			t.Fatalf("table_info: %v", err)
+		}
+		for crows.Next() {
+			var name, typ string
+			var notNull int
+			if err := crows.Scan(&name, &typ, &notNull); err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			cols = append(cols, name+" "+typ+" "+strings.Repeat("NOT NULL", notNull))
+		}
+		crows.Close()
+		slices.Sort(cols)
+		objects = append(objects, table+": "+strings.Join(cols, ", "))
+	}
+	return objects
+}
+
+func TestMigrate_FreshDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "toss.db")
+	d, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+	if v, _ := userVersion(d); v != schemaVersion {
+		t.Errorf("user_version: want %d, got %d", schemaVersion, v)
+	}
+	if matches, _ := filepath.Glob(path + ".v*.bak"); len(matches) != 0 {
+		t.Errorf("a new database needs no backup, got %v", matches)
+	}
+}
+
+func TestMigrate_HistoricalSchemas(t *testing.T) {
+	fresh, err := Open(filepath.Join(t.TempDir(), "fresh.db"))
+	if err != nil {
+		t.Fatalf("Open fresh: %v", err)
+	}
+	want := schemaOf(t, fresh)
+	fresh.Close()
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "schema-*.sql"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no fixtures: %v", err)
+	}
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		t.Run(name, func(t *testing.T) {
+			path := loadFixture(t, name)
+			old, err := sql.Open("sqlite", path)
+			if err != nil {
+				t.Fatalf("sql.Open: %v", err)
+			}
+			version, err := userVersion(old)
+			old.Close()
+			if err != nil {
+				t.Fatalf("reading fixture version: %v", err)
+			}
+
+			d, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer d.Close()
+
+			if v, _ := userVersion(d); v != schemaVersion {
+				t.Errorf("user_version: want %d, got %d", schemaVersion, v)
+			}
+			if got := schemaOf(t, d); !slices.Equal(got, want) {
+				t.Errorf("schema after migration:\n got %v\nwant %v", got, want)
+			}
+
+			entries, err := All(d)
+			if err != nil {
+				t.Fatalf("All: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("want 2 entries kept, got %d", len(entries))
+			}
+			if e := entries[0]; e.OriginalPath != "/home/u/notes.txt" || e.SizeBytes != 12 || e.IsDir {
+				t.Errorf("first entry not preserved: %+v", e)
+			}
+			if e := entries[1]; !e.IsDir || e.SizeBytes != 4096 {
+				t.Errorf("second entry not preserved: %+v", e)
+			}
+			if _, err := Pending(d); err != nil {
+				t.Errorf("Pending: %v", err)
+			}
+			if found, err := FindByQuery(d, "notes"); err != nil || len(found) != 1 {
+				t.Errorf("existing entries should be in the search index, found %d (%v)", len(found), err)
+			}
+
+			backup, err := sql.Open("sqlite", fmt.Sprintf("%s.v%d.bak", path, version))
+			if err != nil {
+				t.Fatalf("opening backup: %v", err)
+			}
+			defer backup.Close()
+			var n int
+			if err := backup.QueryRow(`SELECT COUNT(*) FROM entries`).Scan(&n); err != nil || n != 2 {
+				t.Errorf("backup should hold the 2 original entries, got %d (%v)", n, err)
+			}
+		})
+	}
+}
+
+func TestMigrate_KeepsPendingJournal(t *testing.T) {
+	d, err := Open(loadFixture(t, "schema-v0-journal.sql"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+	ops, err := Pending(d)
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Entry.OriginalPath != "/home/u/draft.md" {
+		t.Errorf("want the pending toss of draft.md, got %+v", ops)
+	}
+}
+
+func TestMigrate_RecordsExistingBatches(t *testing.T) {
+	d, err := Open(loadFixture(t, "schema-v7.sql"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer d.Close()
+	batches, err := LastBatches(d, 5)
+	if err != nil {
+		t.Fatalf("LastBatches: %v", err)
+	}
+	if len(batches) != 2 || batches[0].Command != "rm -r src" || batches[1].Command != "toss notes.txt" {
+		t.Errorf("want both runs, newest first, got %+v", batches)
+	}
+}
+
+func TestMigrate_RejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "toss.db")
+	d, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := d.Exec(`PRAGMA user_version = 999`); err != nil {
+		t.Fatalf("setting user_version: %v", err)
+	}
+	d.Close()
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("want error about a newer schema, got %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+-- The schema of the first release: entries only.
+CREATE TABLE IF NOT EXISTS entries (
+	id           TEXT PRIMARY KEY,
+	original_path TEXT NOT NULL,
+	bin_name      TEXT NOT NULL,
+	tossed_at     DATETIME NOT NULL,
+	is_dir        INTEGER NOT NULL,
+	size_bytes    INTEGER NOT NULL
+);
+INSERT INTO entries VALUES ('11111111-1111-4111-8111-111111111111', '/home/u/notes.txt', '11111111-1111-4111-8111-111111111111-notes.txt', '2026-01-05T09:00:00Z', 0, 12);
+INSERT INTO entries VALUES ('22222222-2222-4222-8222-222222222222', '/home/u/src', '22222222-2222-4222-8222-222222222222-src', '2026-01-06T10:30:00Z', 1, 4096);
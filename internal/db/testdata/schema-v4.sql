@@ -0,0 +1,24 @@
+-- Schema v4, the first versioned schema, before item metadata was kept.
+PRAGMA user_version = 4;
+CREATE TABLE IF NOT EXISTS entries (
+	id           TEXT PRIMARY KEY,
+	original_path TEXT NOT NULL,
+	bin_name      TEXT NOT NULL,
+	tossed_at     DATETIME NOT NULL,
+	is_dir        INTEGER NOT NULL,
+	size_bytes    INTEGER NOT NULL,
+	bin_dir       TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS entries_tossed_at ON entries (tossed_at);
+CREATE INDEX IF NOT EXISTS entries_original_path ON entries (original_path);
+CREATE INDEX IF NOT EXISTS entries_size_bytes ON entries (size_bytes);
+CREATE TABLE IF NOT EXISTS journal (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind       TEXT NOT NULL,
+	state      TEXT NOT NULL,
+	entry      TEXT NOT NULL,
+	dest       TEXT NOT NULL DEFAULT '',
+	started_at DATETIME NOT NULL
+);
+INSERT INTO entries VALUES ('11111111-1111-4111-8111-111111111111', '/home/u/notes.txt', '11111111-1111-4111-8111-111111111111-notes.txt', '2026-01-05T09:00:00Z', 0, 12, '');
+INSERT INTO entries VALUES ('22222222-2222-4222-8222-222222222222', '/mnt/data/src', '22222222-2222-4222-8222-222222222222-src', '2026-01-06T10:30:00Z', 1, 4096, '/mnt/data/.toss-1000/files');
@@ -0,0 +1,49 @@
+-- Schema v7, before toss runs were kept apart from their items.
+PRAGMA user_version = 7;
+CREATE TABLE IF NOT EXISTS entries (
+	id           TEXT PRIMARY KEY,
+	original_path TEXT NOT NULL,
+	bin_name      TEXT NOT NULL,
+	tossed_at     DATETIME NOT NULL,
+	is_dir        INTEGER NOT NULL,
+	size_bytes    INTEGER NOT NULL,
+	bin_dir       TEXT NOT NULL DEFAULT '',
+	mode          INTEGER NOT NULL DEFAULT 0,
+	uid           INTEGER NOT NULL DEFAULT 0,
+	gid           INTEGER NOT NULL DEFAULT 0,
+	mtime         TEXT NOT NULL DEFAULT '',
+	link_target   TEXT NOT NULL DEFAULT '',
+	hostname      TEXT NOT NULL DEFAULT '',
+	cwd           TEXT NOT NULL DEFAULT '',
+	command       TEXT NOT NULL DEFAULT '',
+	user          TEXT NOT NULL DEFAULT '',
+	batch         TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS entries_tossed_at ON entries (tossed_at);
+CREATE INDEX IF NOT EXISTS entries_original_path ON entries (original_path);
+CREATE INDEX IF NOT EXISTS entries_size_bytes ON entries (size_bytes);
+CREATE INDEX entries_batch ON entries (batch);
+CREATE TABLE IF NOT EXISTS journal (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind       TEXT NOT NULL,
+	state      TEXT NOT NULL,
+	entry      TEXT NOT NULL,
+	dest       TEXT NOT NULL DEFAULT '',
+	started_at DATETIME NOT NULL
+);
+CREATE VIRTUAL TABLE entries_fts USING fts5(
+	id UNINDEXED, original_path, bin_name, tokenize = 'trigram'
+);
+CREATE TRIGGER entries_fts_insert AFTER INSERT ON entries BEGIN
+	INSERT INTO entries_fts (id, original_path, bin_name)
+		VALUES (new.id, new.original_path, new.bin_name);
+END;
+CREATE TRIGGER entries_fts_delete AFTER DELETE ON entries BEGIN
+	DELETE FROM entries_fts WHERE id = old.id;
+END;
+CREATE TRIGGER entries_fts_update AFTER UPDATE OF id, original_path, bin_name ON entries BEGIN
+	UPDATE entries_fts SET id = new.id, original_path = new.original_path, bin_name = new.bin_name
+		WHERE id = old.id;
+END;
+INSERT INTO entries VALUES ('11111111-1111-4111-8111-111111111111', '/home/u/notes.txt', '11111111-1111-4111-8111-111111111111-notes.txt', '2026-01-05T09:00:00Z', 0, 12, '', 420, 1000, 1000, '', '', 'host', '/home/u', 'toss notes.txt', 'u', 'aaaaaaaa-aaaa-4aaa-8aaa-aaaaaaaaaaaa');
+INSERT INTO entries VALUES ('22222222-2222-4222-8222-222222222222', '/mnt/data/src', '22222222-2222-4222-8222-222222222222-src', '2026-01-06T10:30:00Z', 1, 4096, '/mnt/data/.toss-1000/files', 2147484141, 1000, 1000, '', '', 'host', '/mnt/data', 'rm -r src', 'u', 'bbbbbbbb-bbbb-4bbb-8bbb-bbbbbbbbbbbb');
@@ -0,0 +1,333 @@
+// Package diff compares the contents of tossed items with each other and
+// with files on disk.
+package diff
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// maxEdits bounds the work done on two very different inputs; beyond it the
+// rest of a is shown as replaced by the rest of b.
+const maxEdits = 2000
+
+type edit struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Compare writes how b differs from a, labelled aName and bName, and
+// reports whether they differ. Text files get a unified diff, binary files
+// a size and hash comparison, and directories a list of the files added,
+// removed and changed.
+func Compare(w io.Writer, a, b, aName, bName string) (bool, error) {
+	aInfo, err := os.Lstat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Lstat(b)
+	if err != nil {
+		return false, err
+	}
+	aKind, bKind := kind(aInfo), kind(bInfo)
+	switch {
+	case aKind != bKind:
+		_, err := fmt.Fprintf(w, "%s is a %s, %s is a %s\n", aName, aKind, bName, bKind)
+		return true, err
+	case aKind == "directory":
+		return Dirs(w, a, b)
+	case aKind == "symlink":
+		aTarget, _ := os.Readlink(a)
+		bTarget, _ := os.Readlink(b)
+		if aTarget == bTarget {
+			return false, nil
+		}
+		_, err := fmt.Fprintf(w, "Symlinks differ: %s -> %s, %s -> %s\n", aName, aTarget, bName, bTarget)
+		return true, err
+	case aKind != "file":
+		return false, fmt.Errorf("cannot compare %ss", aKind)
+	}
+	return Files(w, a, b, aName, bName)
+}
+
+func kind(info fs.FileInfo) string {
+	switch {
+	case info.IsDir():
+		return "directory"
+	case info.Mode()&fs.ModeSymlink != 0:
+		return "symlink"
+	case info.Mode().IsRegular():
+		return "file"
+	default:
+		return "special file"
+	}
+}
+
+// Files writes a unified diff of the regular files at a and b, labelled
+// aName and bName, and reports whether they differ. Binary files are
+// compared by size and SHA-256 instead.
+func Files(w io.Writer, a, b, aName, bName string) (bool, error) {
+	aBinary, err := binaryFile(a)
+	if err != nil {
+		return false, err
+	}
+	bBinary, err := binaryFile(b)
+	if err != nil {
+		return false, err
+	}
+	if aBinary || bBinary {
+		return binaries(w, a, b, aName, bName)
+	}
+
+	aData, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	bData, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(aData, bData) {
+		return false, nil
+	}
+	return true, Unified(w, aName, bName, splitLines(aData), splitLines(bData))
+}
+
+func binaries(w io.Writer, a, b, aName, bName string) (bool, error) {
+	aSum, aSize, err := fileHash(a)
+	if err != nil {
+		return false, err
+	}
+	bSum, bSize, err := fileHash(b)
+	if err != nil {
+		return false, err
+	}
+	if aSum == bSum && aSize == bSize {
+		return false, nil
+	}
+	_, err = fmt.Fprintf(w, "Binary files differ:\n  %s: %d bytes, sha256 %s\n  %s: %d bytes, sha256 %s\n",
+		aName, aSize, aSum, bName, bSize, bSum)
+	return true, err
+}
+
+// binaryFile guesses, like git and diff, that a file with a NUL byte near
+// the start is not text.
+func binaryFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	head := make([]byte, 8000)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return bytes.IndexByte(head[:n], 0) >= 0, nil
+}
+
+func fileHash(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// splitLines splits data after each newline; a last line without one is
+// kept as it is.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Unified writes the changes from a to b, lines that keep their newlines,
+// in unified format. Nothing is written if they are equal.
+func Unified(w io.Writer, aName, bName string, a, b []string) error {
+	es := edits(a, b)
+	header := false
+	aLine, bLine := 0, 0
+	for i := 0; i < len(es); {
+		// Skip to the next change, counting lines as we go.
+		j := i
+		for j < len(es) && es[j].kind == ' ' {
+			j++
+		}
+		if j == len(es) {
+			break
+		}
+		start := max(j-context, i)
+		for k := i; k < start; k++ {
+			aLine++
+			bLine++
+		}
+
+		// Extend the hunk over changes separated by little enough context.
+		end := j
+		for {
+			for end < len(es) && es[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(es) && es[next].kind == ' ' {
+				next++
+			}
+			if next < len(es) && next-end <= 2*context {
+				end = next
+				continue
+			}
+			end = min(end+context, len(es))
+			break
+		}
+
+		if !header {
+			if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", aName, bName); err != nil {
+				return err
+			}
+			header = true
+		}
+		aCount, bCount := 0, 0
+		for _, e := range es[start:end] {
+			if e.kind != '+' {
+				aCount++
+			}
+			if e.kind != '-' {
+				bCount++
+			}
+		}
+		if _, err := fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount)); err != nil {
+			return err
+		}
+		for _, e := range es[start:end] {
+			if err := writeLine(w, e); err != nil {
+				return err
+			}
+		}
+		aLine += aCount
+		bLine += bCount
+		i = end
+	}
+	return nil
+}
+
+func hunkRange(line, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line)
+	case 1:
+		return fmt.Sprintf("%d", line+1)
+	default:
+		return fmt.Sprintf("%d,%d", line+1, count)
+	}
+}
+
+func writeLine(w io.Writer, e edit) error {
+	line := string(e.kind) + e.line
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n\\ No newline at end of file\n"
+	}
+	_, err := io.WriteString(w, line)
+	return err
+}
+
+// edits finds a shortest edit script from a to b with Myers' algorithm.
+func edits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	off := limit + 1
+	v := make([]int, 2*off+1)
+	var trace [][]int
+	d, x, y := 0, 0, 0
+search:
+	for ; d <= limit; d++ {
+		// Only diagonals -d-1..d+1 are read when backtracking from step d.
+		trace = append(trace, append([]int(nil), v[off-d-1:off+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x == n && y == m {
+				break search
+			}
+		}
+	}
+
+	var rest []edit
+	if d > limit {
+		// Give up on the tail: from the furthest point reached inside both
+		// inputs (diagonals can run past their ends), replace the rest
+		// wholesale.
+		d = limit
+		x, y = -1, -1
+		for k := -d; k <= d; k += 2 {
+			kx, ky := v[off+k], v[off+k]-k
+			if kx <= n && ky <= m && kx+ky > x+y {
+				x, y = kx, ky
+			}
+		}
+		for _, line := range a[x:] {
+			rest = append(rest, edit{'-', line})
+		}
+		for _, line := range b[y:] {
+			rest = append(rest, edit{'+', line})
+		}
+	}
+
+	var es []edit
+	for ; d >= 0; d-- {
+		snap := trace[d]
+		at := func(k int) int { return snap[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			es = append(es, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				es = append(es, edit{'+', b[y-1]})
+			} else {
+				es = append(es, edit{'-', a[x-1]})
+			}
+			x, y = prevX, prevY
+		}
+	}
+	for i, j := 0, len(es)-1; i < j; i, j = i+1, j-1 {
+		es[i], es[j] = es[j], es[i]
+	}
+	return append(es, rest...)
+}
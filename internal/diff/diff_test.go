@@ -0,0 +1,210 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return splitLines([]byte(s))
+}
+
+func unified(t *testing.T, a, b string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Unified(&buf, "a", "b", lines(a), lines(b)); err != nil {
+		t.Fatalf("Unified: %v", err)
+	}
+	return buf.String()
+}
+
+func TestUnified_Equal(t *testing.T) {
+	if got := unified(t, "x\ny\n", "x\ny\n"); got != "" {
+		t.Errorf("equal inputs should produce no output, got:\n%s", got)
+	}
+}
+
+func TestUnified_OneChange(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+	want := "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if got := unified(t, a, b); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var a, b strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&a, "%d\n", i)
+		switch i {
+		case 2, 18:
+			fmt.Fprintf(&b, "changed %d\n", i)
+		default:
+			fmt.Fprintf(&b, "%d\n", i)
+		}
+	}
+	got := unified(t, a.String(), b.String())
+	if n := strings.Count(got, "@@ -"); n != 2 {
+		t.Errorf("want 2 hunks, got %d:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") || !strings.Contains(got, "@@ -15,6 +15,6 @@") {
+		t.Errorf("unexpected hunk ranges:\n%s", got)
+	}
+}
+
+func TestUnified_InsertIntoEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := unified(t, "", "x\ny\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnified_NoNewlineAtEnd(t *testing.T) {
+	got := unified(t, "x\n", "x")
+	want := "--- a\n+++ b\n@@ -1 +1 @@\n-x\n+x\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEdits_ReproducesBothSides(t *testing.T) {
+	cases := [][2]string{
+		{"a\nb\nc\n", "b\nc\nd\n"},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+		{"", "a\n"},
+		{"a\n", ""},
+	}
+	for _, c := range cases {
+		var a, b strings.Builder
+		for _, e := range edits(lines(c[0]), lines(c[1])) {
+			if e.kind != '+' {
+				a.WriteString(e.line)
+			}
+			if e.kind != '-' {
+				b.WriteString(e.line)
+			}
+		}
+		if a.String() != c[0] || b.String() != c[1] {
+			t.Errorf("edits(%q, %q) rebuild %q, %q", c[0], c[1], a.String(), b.String())
+		}
+	}
+}
+
+func TestEdits_GivesUpOnHugeDifferences(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+	a = append([]string{"same\n"}, a...)
+	b = append([]string{"same\n"}, b...)
+	es := edits(a, b)
+	if es[0] != (edit{' ', "same\n"}) {
+		t.Errorf("common prefix should be kept, got %+v", es[0])
+	}
+	if len(es) != 1+2*maxEdits {
+		t.Errorf("want %d edits, got %d", 1+2*maxEdits, len(es))
+	}
+}
+
+func TestFiles_Binary(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("x\x00y"), 0644)
+	os.WriteFile(b, []byte("x\x00z"), 0644)
+	var buf bytes.Buffer
+	differ, err := Files(&buf, a, b, "a", "b")
+	if err != nil || !differ {
+		t.Fatalf("Files: differ %v, err %v", differ, err)
+	}
+	for _, want := range []string{"Binary files differ", "a: 3 bytes, sha256 ", "b: 3 bytes, sha256 "} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q: %q", want, buf.String())
+		}
+	}
+}
+
+func TestFiles_Same(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("same\n"), 0644)
+	os.WriteFile(b, []byte("same\n"), 0644)
+	var buf bytes.Buffer
+	if differ, err := Files(&buf, a, b, "a", "b"); err != nil || differ || buf.Len() != 0 {
+		t.Errorf("equal files: differ %v, err %v, output %q", differ, err, buf.String())
+	}
+}
+
+func TestCompare_Dirs(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	for path, content := range map[string]string{
+		"a/same.txt":       "same",
+		"a/changed.txt":    "old",
+		"a/removed.txt":    "x",
+		"a/olddir/one.txt": "1",
+		"a/becomes-dir":    "file",
+		"b/same.txt":       "same",
+		"b/changed.txt":    "new",
+		"b/added.txt":      "y",
+		"b/newdir/two.txt": "2",
+		"b/becomes-dir/x":  "inside",
+	} {
+		path = filepath.Join(dir, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	var buf bytes.Buffer
+	differ, err := Compare(&buf, a, b, "a", "b")
+	if err != nil || !differ {
+		t.Fatalf("Compare: differ %v, err %v", differ, err)
+	}
+	want := "added:   added.txt\n" +
+		"changed: becomes-dir/\n" +
+		"changed: changed.txt\n" +
+		"added:   newdir/\n" +
+		"removed: olddir/\n" +
+		"removed: removed.txt\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestCompare_DirsSiblingSortsBetween(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	// The added "a-x" sorts between the removed "a" and "a/b".
+	for _, path := range []string{"a/a/b", "a/a/c", "b/a-x/y"} {
+		path = filepath.Join(dir, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("x"), 0644)
+	}
+
+	var buf bytes.Buffer
+	if _, err := Dirs(&buf, a, b); err != nil {
+		t.Fatalf("Dirs: %v", err)
+	}
+	if want := "removed: a/\nadded:   a-x/\n"; buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestCompare_KindMismatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "f")
+	os.WriteFile(file, []byte("x"), 0644)
+	var buf bytes.Buffer
+	differ, err := Compare(&buf, file, dir, "f", "d")
+	if err != nil || !differ || buf.String() != "f is a file, d is a directory\n" {
+		t.Errorf("differ %v, err %v, output %q", differ, err, buf.String())
+	}
+}
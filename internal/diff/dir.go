@@ -0,0 +1,137 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Dirs writes the files added to, removed from and changed between the
+// trees at a and b, one per line, and reports whether there were any. The
+// contents of a directory that was added, removed or turned into a file are
+// not listed separately.
+func Dirs(w io.Writer, a, b string) (bool, error) {
+	aTree, err := tree(a)
+	if err != nil {
+		return false, err
+	}
+	bTree, err := tree(b)
+	if err != nil {
+		return false, err
+	}
+
+	paths := make([]string, 0, len(aTree)+len(bTree))
+	for rel := range aTree {
+		paths = append(paths, rel)
+	}
+	for rel := range bTree {
+		if _, ok := aTree[rel]; !ok {
+			paths = append(paths, rel)
+		}
+	}
+	slices.Sort(paths)
+
+	differ := false
+	gone := make(map[string]bool) // directories reported, whose contents are skipped
+	report := func(change, rel string, info fs.FileInfo, dir bool) error {
+		differ = true
+		name := rel
+		if info.IsDir() {
+			name += "/"
+		}
+		if dir {
+			gone[rel] = true
+		}
+		_, err := fmt.Fprintf(w, "%-9s%s\n", change+":", name)
+		return err
+	}
+	for _, rel := range paths {
+		// Siblings such as "a-x" sort between "a" and "a/b", so it is not
+		// enough to look at the last directory reported.
+		if insideAny(rel, gone) {
+			continue
+		}
+		aInfo, inA := aTree[rel]
+		bInfo, inB := bTree[rel]
+		var err error
+		switch {
+		case !inB:
+			err = report("removed", rel, aInfo, aInfo.IsDir())
+		case !inA:
+			err = report("added", rel, bInfo, bInfo.IsDir())
+		default:
+			var same bool
+			same, err = sameItem(filepath.Join(a, rel), filepath.Join(b, rel), aInfo, bInfo)
+			if err == nil && !same {
+				// A file replaced by a directory, or the other way round.
+				err = report("changed", rel, bInfo, aInfo.IsDir() || bInfo.IsDir())
+			}
+		}
+		if err != nil {
+			return differ, err
+		}
+	}
+	return differ, nil
+}
+
+// insideAny reports whether one of dirs is an ancestor of rel.
+func insideAny(rel string, dirs map[string]bool) bool {
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// tree maps the path of everything below root, relative to it, to its
+// Lstat info.
+func tree(root string) (map[string]fs.FileInfo, error) {
+	items := make(map[string]fs.FileInfo)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		items[rel] = info
+		return nil
+	})
+	return items, err
+}
+
+func sameItem(a, b string, aInfo, bInfo fs.FileInfo) (bool, error) {
+	aKind, bKind := kind(aInfo), kind(bInfo)
+	switch {
+	case aKind != bKind:
+		return false, nil
+	case aKind == "directory":
+		return true, nil
+	case aKind == "symlink":
+		aTarget, _ := os.Readlink(a)
+		bTarget, _ := os.Readlink(b)
+		return aTarget == bTarget, nil
+	case aKind != "file":
+		return aInfo.Mode() == bInfo.Mode(), nil
+	case aInfo.Size() != bInfo.Size():
+		return false, nil
+	}
+	aSum, _, err := fileHash(a)
+	if err != nil {
+		return false, err
+	}
+	bSum, _, err := fileHash(b)
+	if err != nil {
+		return false, err
+	}
+	return aSum == bSum, nil
+}
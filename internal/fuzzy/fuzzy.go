@@ -0,0 +1,163 @@
+// Package fuzzy ranks strings by how well they match a pattern whose
+// characters appear in them in order, but not necessarily together, the
+// way fzf and editor file pickers do.
+package fuzzy
+
+import (
+	"slices"
+	"unicode"
+)
+
+// Scoring: every matched character is worth matchScore. Matches that
+// continue the previous one, or that start a word, are worth more; gaps
+// between matches cost a little, and more for opening a gap than for
+// widening it.
+const (
+	matchScore    = 16
+	consecutive   = 16
+	boundaryFirst = 10
+	boundarySlash = 9
+	boundaryOther = 8
+	boundaryCamel = 7
+	gapStart      = 3
+	gapExtension  = 1
+	unmatched     = -1 << 30
+	noPrev        = -1
+)
+
+// Match is one of the texts passed to Rank that matched, with the rune
+// offsets of the matched characters for highlighting.
+type Match struct {
+	Index     int
+	Score     int
+	Positions []int
+}
+
+// Rank returns the texts that match pattern, best first. Ties go to the
+// shorter text, then to the earlier one. An empty pattern matches every
+// text, in order.
+func Rank(pattern string, texts []string) []Match {
+	var matches []Match
+	for i, text := range texts {
+		if score, positions, ok := Score(pattern, text); ok {
+			matches = append(matches, Match{Index: i, Score: score, Positions: positions})
+		}
+	}
+	if pattern == "" {
+		return matches
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+		return len(texts[a.Index]) - len(texts[b.Index])
+	})
+	return matches
+}
+
+// Score reports whether every rune of pattern occurs in text in order,
+// ignoring case, and if so how well the best such alignment scores and
+// where in text, in runes, it matched.
+func Score(pattern, text string) (int, []int, bool) {
+	p := lower([]rune(pattern))
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+	orig := []rune(text)
+	t := lower(orig)
+	if !subsequence(p, t) {
+		return 0, nil, false
+	}
+
+	m, n := len(p), len(t)
+	// score[i][j] is the best score of matching p[:i+1] with p[i] at
+	// t[j]; from[i][j] is where p[i-1] was then matched.
+	score := make([][]int, m)
+	from := make([][]int, m)
+	for i := range p {
+		score[i] = make([]int, n)
+		from[i] = make([]int, n)
+		// The best score of p[:i] ending two or more runes back, less the
+		// cost of the gap to j, and where it ended.
+		gapBest, gapFrom := unmatched, noPrev
+		for j := range t {
+			if i > 0 && j >= 2 {
+				if s := score[i-1][j-2]; s != unmatched && s-gapStart > gapBest-gapExtension {
+					gapBest, gapFrom = s-gapStart, j-2
+				} else if gapBest != unmatched {
+					gapBest -= gapExtension
+				}
+			}
+			score[i][j], from[i][j] = unmatched, noPrev
+			if t[j] != p[i] {
+				continue
+			}
+			bonus := boundary(orig, j)
+			if i == 0 {
+				score[i][j] = matchScore + bonus
+				continue
+			}
+			if j > 0 && score[i-1][j-1] != unmatched {
+				score[i][j] = score[i-1][j-1] + matchScore + max(consecutive, bonus)
+				from[i][j] = j - 1
+			}
+			if gapBest != unmatched && gapBest+matchScore+bonus > score[i][j] {
+				score[i][j] = gapBest + matchScore + bonus
+				from[i][j] = gapFrom
+			}
+		}
+	}
+
+	best, end := unmatched, noPrev
+	for j, s := range score[m-1] {
+		if s > best {
+			best, end = s, j
+		}
+	}
+	positions := make([]int, m)
+	for i := m - 1; i >= 0; i-- {
+		positions[i] = end
+		end = from[i][end]
+	}
+	return best, positions, true
+}
+
+// Matches reports whether every rune of pattern occurs in text in order,
+// ignoring case: whether Score would match, without scoring.
+func Matches(pattern, text string) bool {
+	return subsequence(lower([]rune(pattern)), lower([]rune(text)))
+}
+
+func subsequence(p, t []rune) bool {
+	i := 0
+	for _, r := range t {
+		if i < len(p) && r == p[i] {
+			i++
+		}
+	}
+	return i == len(p)
+}
+
+// boundary is the bonus for a match at t[j] starting a word.
+func boundary(t []rune, j int) int {
+	if j == 0 {
+		return boundaryFirst
+	}
+	switch prev := t[j-1]; {
+	case prev == '/':
+		return boundarySlash
+	case prev == '-' || prev == '_' || prev == '.' || prev == ' ':
+		return boundaryOther
+	case unicode.IsLower(prev) && unicode.IsUpper(t[j]):
+		return boundaryCamel
+	}
+	return 0
+}
+
+func lower(rs []rune) []rune {
+	out := make([]rune, len(rs))
+	for i, r := range rs {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScore_Subsequence(t *testing.T) {
+	cases := []struct {
+		pattern, text string
+		ok            bool
+	}{
+		{"", "anything", true},
+		{"abc", "a_b_c", true},
+		{"ABC", "xaxbxc", true},
+		{"abc", "acb", false},
+		{"notes", "/home/u/notes.txt", true},
+		{"é", "café", true},
+	}
+	for _, c := range cases {
+		if _, _, ok := Score(c.pattern, c.text); ok != c.ok {
+			t.Errorf("Score(%q, %q): want ok %v", c.pattern, c.text, c.ok)
+		}
+		if ok := Matches(c.pattern, c.text); ok != c.ok {
+			t.Errorf("Matches(%q, %q): want %v", c.pattern, c.text, c.ok)
+		}
+	}
+}
+
+func TestScore_Positions(t *testing.T) {
+	cases := []struct {
+		pattern, text string
+		want          []int
+	}{
+		{"ab", "xaxb", []int{1, 3}},
+		{"main", "/a/main.go", []int{3, 4, 5, 6}},
+		// The t starting the extension beats the nearer one inside a word.
+		{"nt", "/src/notes.txt", []int{5, 11}},
+		{"Ab", "xaAB", []int{2, 3}},
+	}
+	for _, c := range cases {
+		_, positions, ok := Score(c.pattern, c.text)
+		if !ok || !slices.Equal(positions, c.want) {
+			t.Errorf("Score(%q, %q): want positions %v, got %v (ok %v)", c.pattern, c.text, c.want, positions, ok)
+		}
+	}
+}
+
+func TestScore_PrefersWordStartsAndRuns(t *testing.T) {
+	better, _, _ := Score("conf", "/etc/app/config.yml")
+	worse, _, _ := Score("conf", "/home/u/cache/onef")
+	if better <= worse {
+		t.Errorf("a consecutive match should outscore a scattered one: %d <= %d", better, worse)
+	}
+	start, _, _ := Score("rd", "/x/readme")
+	middle, _, _ := Score("rd", "/x/bread")
+	if start <= middle {
+		t.Errorf("a match at a word start should outscore one inside a word: %d <= %d", start, middle)
+	}
+}
+
+func TestRank_OrdersByScore(t *testing.T) {
+	texts := []string{
+		"/home/u/src/main.go",
+		"/home/u/mail/archive-now",
+		"/home/u/main",
+		"/home/u/other",
+	}
+	matches := Rank("main", texts)
+	var got []int
+	for _, m := range matches {
+		got = append(got, m.Index)
+	}
+	if want := []int{2, 0, 1}; !slices.Equal(got, want) {
+		t.Errorf("ranking: want %v, got %v", want, got)
+	}
+}
+
+func TestRank_EmptyPatternKeepsOrder(t *testing.T) {
+	matches := Rank("", []string{"b", "a", "c"})
+	if len(matches) != 3 || matches[0].Index != 0 || matches[2].Index != 2 {
+		t.Errorf("empty pattern should keep every text in order, got %+v", matches)
+	}
+}
@@ -0,0 +1,45 @@
+package gc
+
+import (
+	"time"
+
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// Select returns the entries to delete so that what remains satisfies r.
+// entries must be ordered oldest first, as db.All returns them; the oldest
+// items are always the first to go.
+func Select(entries []db.Entry, r config.Retention, now time.Time) []db.Entry {
+	expired, _ := SelectKeeping(entries, r, now, nil)
+	return expired
+}
+
+// SelectKeeping is Select that never picks the entries whose IDs are in
+// keep, such as those just tossed. It also reports whether what remains
+// still breaks r, which only the kept entries can cause.
+func SelectKeeping(entries []db.Entry, r config.Retention, now time.Time, keep map[string]bool) ([]db.Entry, bool) {
+	var total int64
+	for _, e := range entries {
+		total += e.SizeBytes
+	}
+	count := len(entries)
+
+	var expired []db.Entry
+	for _, e := range entries {
+		tooOld := r.MaxAge > 0 && now.Sub(e.TossedAt) > r.MaxAge
+		tooMany := r.MaxItems > 0 && count > r.MaxItems
+		tooBig := r.MaxSize > 0 && total > r.MaxSize
+		if !tooOld && !tooMany && !tooBig {
+			return expired, false
+		}
+		if keep[e.ID] {
+			continue
+		}
+		expired = append(expired, e)
+		count--
+		total -= e.SizeBytes
+	}
+	over := r.MaxItems > 0 && count > r.MaxItems || r.MaxSize > 0 && total > r.MaxSize
+	return expired, over
+}
@@ -0,0 +1,99 @@
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+)
+
+var now = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// makeEntries returns entries tossed ages[i] ago, oldest first.
+func makeEntries(sizes []int64, ages []time.Duration) []db.Entry {
+	entries := make([]db.Entry, len(sizes))
+	for i := range entries {
+		entries[i] = db.Entry{
+			ID:        string(rune('a' + i)),
+			TossedAt:  now.Add(-ages[i]),
+			SizeBytes: sizes[i],
+		}
+	}
+	return entries
+}
+
+func ids(entries []db.Entry) string {
+	var s string
+	for _, e := range entries {
+		s += e.ID
+	}
+	return s
+}
+
+func TestSelect_NoPolicy(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{1, 2}, []time.Duration{48 * time.Hour, time.Hour})
+	if got := Select(entries, config.Retention{}, now); len(got) != 0 {
+		t.Errorf("empty policy should select nothing, got %q", ids(got))
+	}
+}
+
+func TestSelect_MaxAge(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{1, 1, 1}, []time.Duration{72 * time.Hour, 49 * time.Hour, time.Hour})
+	got := Select(entries, config.Retention{MaxAge: 48 * time.Hour}, now)
+	if ids(got) != "ab" {
+		t.Errorf("want ab, got %q", ids(got))
+	}
+}
+
+func TestSelect_MaxItems(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{1, 1, 1, 1}, []time.Duration{4 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour})
+	got := Select(entries, config.Retention{MaxItems: 3}, now)
+	if ids(got) != "a" {
+		t.Errorf("want a, got %q", ids(got))
+	}
+}
+
+func TestSelect_MaxSize(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{100, 50, 30, 20}, []time.Duration{4 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour})
+	got := Select(entries, config.Retention{MaxSize: 60}, now)
+	if ids(got) != "ab" {
+		t.Errorf("want ab (leaving 50 bytes), got %q", ids(got))
+	}
+}
+
+func TestSelect_CombinedRules(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{10, 10, 10}, []time.Duration{100 * time.Hour, 2 * time.Hour, time.Hour})
+	got := Select(entries, config.Retention{MaxAge: 50 * time.Hour, MaxItems: 1}, now)
+	if ids(got) != "ab" {
+		t.Errorf("want ab, got %q", ids(got))
+	}
+}
+
+func TestSelect_WithinLimits(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{10, 10}, []time.Duration{2 * time.Hour, time.Hour})
+	got := Select(entries, config.Retention{MaxAge: 24 * time.Hour, MaxSize: 100, MaxItems: 5}, now)
+	if len(got) != 0 {
+		t.Errorf("want nothing selected, got %q", ids(got))
+	}
+}
+
+func TestSelectKeeping_SparesKept(t *testing.T) {
+	t.Parallel()
+	entries := makeEntries([]int64{100, 50, 4096}, []time.Duration{3 * time.Hour, 2 * time.Hour, 0})
+	got, over := SelectKeeping(entries, config.Retention{MaxSize: 1024}, now, map[string]bool{"c": true})
+	if ids(got) != "ab" || !over {
+		t.Errorf("want ab and still over the limit, got %q, %v", ids(got), over)
+	}
+
+	got, over = SelectKeeping(entries, config.Retention{MaxSize: 4200}, now, map[string]bool{"c": true})
+	if ids(got) != "a" || over {
+		t.Errorf("want a and within the limit, got %q, %v", ids(got), over)
+	}
+}
@@ -0,0 +1,62 @@
+// Package lock provides advisory file locks shared between toss processes.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// File is a lock file. Any number of processes may hold it shared, or one
+// exclusively. Locks are released when the file is closed or the process
+// exits.
+type File struct {
+	f *os.File
+}
+
+func Open(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock: %w", err)
+	}
+	return &File{f: f}, nil
+}
+
+// Shared waits for a shared lock, downgrading an exclusive one.
+func (l *File) Shared() error {
+	return l.flock(unix.LOCK_SH)
+}
+
+// Exclusive waits for an exclusive lock, upgrading a shared one. The upgrade
+// is not atomic: other processes may take the lock in between.
+func (l *File) Exclusive() error {
+	return l.flock(unix.LOCK_EX)
+}
+
+// TryExclusive takes an exclusive lock if no other process holds the lock.
+func (l *File) TryExclusive() (bool, error) {
+	err := l.flock(unix.LOCK_EX | unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *File) Close() error {
+	return l.f.Close()
+}
+
+func (l *File) flock(how int) error {
+	for {
+		err := unix.Flock(int(l.f.Fd()), how)
+		if !errors.Is(err, unix.EINTR) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package lock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTwo(t *testing.T) (*File, *File) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "toss.db.lock")
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	b, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { a.Close(); b.Close() })
+	return a, b
+}
+
+func TestSharedLocksCoexist(t *testing.T) {
+	a, b := openTwo(t)
+	if err := a.Shared(); err != nil {
+		t.Fatalf("Shared: %v", err)
+	}
+	if err := b.Shared(); err != nil {
+		t.Fatalf("second Shared: %v", err)
+	}
+	if ok, _ := b.TryExclusive(); ok {
+		t.Error("TryExclusive should fail while another holder is shared")
+	}
+}
+
+func TestTryExclusive_WhenFree(t *testing.T) {
+	a, b := openTwo(t)
+	ok, err := a.TryExclusive()
+	if err != nil || !ok {
+		t.Fatalf("TryExclusive: %v %v", ok, err)
+	}
+	if ok, _ := b.TryExclusive(); ok {
+		t.Error("second TryExclusive should fail")
+	}
+	if err := a.Shared(); err != nil {
+		t.Fatalf("downgrade: %v", err)
+	}
+	if err := b.Shared(); err != nil {
+		t.Fatalf("Shared after downgrade: %v", err)
+	}
+}
+
+func TestExclusiveWaitsForShared(t *testing.T) {
+	a, b := openTwo(t)
+	if err := a.Shared(); err != nil {
+		t.Fatalf("Shared: %v", err)
+	}
+	got := make(chan struct{})
+	go func() {
+		b.Exclusive()
+		close(got)
+	}()
+	select {
+	case <-got:
+		t.Fatal("Exclusive should wait while a shared lock is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	a.Close()
+	select {
+	case <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Exclusive should succeed once the shared lock is released")
+	}
+}
@@ -0,0 +1,149 @@
+// Package rmcompat parses the command line of GNU rm, so that toss can
+// stand in for it.
+package rmcompat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// When to prompt, as set by -f, -i, -I and --interactive.
+const (
+	PromptDefault = ""       // only for write-protected files, on a terminal
+	PromptNever   = "never"  // -f
+	PromptAlways  = "always" // -i
+)
+
+// Options are the options given to rm.
+type Options struct {
+	Force           bool   // -f: ignore missing operands
+	Prompt          string // one of the Prompt constants
+	PromptOnce      bool   // -I: one prompt for more than 3 operands or -r
+	Recursive       bool   // -r, -R
+	Dir             bool   // -d: remove empty directories
+	Verbose         bool   // -v
+	OneFileSystem   bool   // skip directories on other file systems
+	PreserveRoot    bool   // refuse to remove / recursively; on by default
+	PreserveRootAll bool   // also refuse operands on another device than their parent
+	Help, Version   bool
+}
+
+// longOptions are rm's long options; those taking an optional argument
+// end in "=".
+var longOptions = []string{
+	"force", "interactive=", "one-file-system", "no-preserve-root",
+	"preserve-root=", "recursive", "dir", "verbose", "help", "version",
+}
+
+// Parse parses args, rm's arguments without the program name, into
+// options and operands. As with GNU rm, options may follow operands, and
+// "--" ends the options. Long options may be abbreviated to any unique
+// prefix. Errors read like rm's, without the "rm: " prefix.
+func Parse(args []string) (Options, []string, error) {
+	o := Options{PreserveRoot: true}
+	var operands []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return o, append(operands, args[i+1:]...), nil
+		case strings.HasPrefix(arg, "--"):
+			if err := o.long(arg); err != nil {
+				return o, nil, err
+			}
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			for _, c := range arg[1:] {
+				if err := o.short(c); err != nil {
+					return o, nil, err
+				}
+			}
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return o, operands, nil
+}
+
+func (o *Options) short(c rune) error {
+	switch c {
+	case 'f':
+		o.Force, o.Prompt, o.PromptOnce = true, PromptNever, false
+	case 'i':
+		o.Force, o.Prompt, o.PromptOnce = false, PromptAlways, false
+	case 'I':
+		o.Force, o.Prompt, o.PromptOnce = false, PromptDefault, true
+	case 'r', 'R':
+		o.Recursive = true
+	case 'd':
+		o.Dir = true
+	case 'v':
+		o.Verbose = true
+	default:
+		return fmt.Errorf("invalid option -- '%c'", c)
+	}
+	return nil
+}
+
+func (o *Options) long(arg string) error {
+	name, value, hasValue := strings.Cut(arg[2:], "=")
+	var matches []string
+	for _, opt := range longOptions {
+		if strings.HasPrefix(strings.TrimSuffix(opt, "="), name) {
+			if strings.TrimSuffix(opt, "=") == name {
+				matches = []string{opt}
+				break
+			}
+			matches = append(matches, opt)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return fmt.Errorf("unrecognized option '%s'", arg)
+	case len(matches) > 1:
+		return fmt.Errorf("option '%s' is ambiguous", arg)
+	}
+	opt := matches[0]
+	if hasValue && !strings.HasSuffix(opt, "=") {
+		return fmt.Errorf("option '--%s' doesn't allow an argument", opt)
+	}
+
+	switch strings.TrimSuffix(opt, "=") {
+	case "force":
+		return o.short('f')
+	case "interactive":
+		switch value {
+		case "never", "no", "none":
+			o.Prompt, o.PromptOnce = PromptNever, false
+		case "once":
+			o.short('I')
+		case "always", "yes", "":
+			o.short('i')
+		default:
+			return fmt.Errorf("invalid argument '%s' for '--interactive'", value)
+		}
+	case "one-file-system":
+		o.OneFileSystem = true
+	case "no-preserve-root":
+		o.PreserveRoot, o.PreserveRootAll = false, false
+	case "preserve-root":
+		switch value {
+		case "":
+		case "all":
+			o.PreserveRootAll = true
+		default:
+			return fmt.Errorf("unrecognized --preserve-root argument: '%s'", value)
+		}
+		o.PreserveRoot = true
+	case "recursive":
+		o.Recursive = true
+	case "dir":
+		o.Dir = true
+	case "verbose":
+		o.Verbose = true
+	case "help":
+		o.Help = true
+	case "version":
+		o.Version = true
+	}
+	return nil
+}
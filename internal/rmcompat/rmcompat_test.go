@@ -0,0 +1,90 @@
+package rmcompat
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		args     []string
+		want     Options
+		operands []string
+	}{
+		{
+			args:     []string{"a", "b"},
+			want:     Options{PreserveRoot: true},
+			operands: []string{"a", "b"},
+		},
+		{
+			args:     []string{"-rf", "dir", "-v"},
+			want:     Options{Force: true, Prompt: PromptNever, Recursive: true, Verbose: true, PreserveRoot: true},
+			operands: []string{"dir"},
+		},
+		{
+			// The last of -f, -i and -I wins.
+			args:     []string{"-f", "-i", "x"},
+			want:     Options{Prompt: PromptAlways, PreserveRoot: true},
+			operands: []string{"x"},
+		},
+		{
+			args:     []string{"-if", "x"},
+			want:     Options{Force: true, Prompt: PromptNever, PreserveRoot: true},
+			operands: []string{"x"},
+		},
+		{
+			args:     []string{"-I", "-Rd", "--", "-f", "-"},
+			want:     Options{PromptOnce: true, Recursive: true, Dir: true, PreserveRoot: true},
+			operands: []string{"-f", "-"},
+		},
+		{
+			args:     []string{"--rec", "--verb", "--one-file-system", "--interactive=once", "x"},
+			want:     Options{PromptOnce: true, Recursive: true, Verbose: true, OneFileSystem: true, PreserveRoot: true},
+			operands: []string{"x"},
+		},
+		{
+			args:     []string{"--interactive", "--no-preserve-root", "/"},
+			want:     Options{Prompt: PromptAlways},
+			operands: []string{"/"},
+		},
+		{
+			args: []string{"--preserve-root=all", "--force", "--interactive=never"},
+			want: Options{Force: true, Prompt: PromptNever, PreserveRoot: true, PreserveRootAll: true},
+		},
+		{
+			args: []string{"--help"},
+			want: Options{Help: true, PreserveRoot: true},
+		},
+	}
+	for _, c := range cases {
+		got, operands, err := Parse(c.args)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.args, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("Parse(%q):\n got %+v\nwant %+v", c.args, got, c.want)
+		}
+		if !slices.Equal(operands, c.operands) {
+			t.Errorf("Parse(%q) operands = %q, want %q", c.args, operands, c.operands)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		"-x":                   "invalid option -- 'x'",
+		"-rx":                  "invalid option -- 'x'",
+		"--bogus":              "unrecognized option '--bogus'",
+		"--ver":                "option '--ver' is ambiguous",
+		"--force=yes":          "option '--force' doesn't allow an argument",
+		"--interactive=maybe":  "invalid argument 'maybe' for '--interactive'",
+		"--preserve-root=some": "unrecognized --preserve-root argument: 'some'",
+	}
+	for arg, want := range cases {
+		_, _, err := Parse([]string{arg, "file"})
+		if err == nil || err.Error() != want {
+			t.Errorf("Parse(%q): got error %v, want %q", arg, err, want)
+		}
+	}
+}
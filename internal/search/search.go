@@ -0,0 +1,227 @@
+// Package search finds tossed items by a query: text matched against their
+// original paths in one of several modes, narrowed by qualifiers such as
+// dir:, name:, before: and size>.
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/roman91DE/toss/internal/config"
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/fuzzy"
+)
+
+// Ways of matching the text of a query against a path.
+const (
+	Substring = "substring" // case-insensitive substring, as toss has always matched
+	Fuzzy     = "fuzzy"     // characters in order, ranked by how well they match
+	Glob      = "glob"      // SQLite GLOB against the whole path
+	Regex     = "regex"     // Go regular expression
+)
+
+// Modes lists every mode.
+var Modes = []string{Substring, Fuzzy, Glob, Regex}
+
+// Query is a parsed search query. Zero fields don't restrict anything.
+type Query struct {
+	Text      string    // matched against the original path
+	Dir       string    // dir:D, a directory the item was in, at any depth
+	Name      string    // name:N, matched against the base name only
+	Before    time.Time // before:T
+	After     time.Time // after:T
+	MinSize   int64     // size>N, as N+1
+	SizeBelow int64     // size<N
+}
+
+// Parse splits s into qualifiers and the text left over, which is joined
+// with single spaces. Qualifiers are dir:, name:, before: and after:
+// followed by a value, and size> or size< followed by a size. A dir: value
+// that is a path (starting with /, ~ or .) means that directory and
+// everything below it; any other value is a substring of the directory.
+func Parse(s string, now time.Time) (Query, error) {
+	var q Query
+	var text []string
+	for _, field := range strings.Fields(s) {
+		key, value, ok := cutQualifier(field)
+		if !ok {
+			text = append(text, field)
+			continue
+		}
+		if value == "" {
+			return q, fmt.Errorf("%q needs a value", key)
+		}
+		var err error
+		switch key {
+		case "dir:":
+			q.Dir, err = dirValue(value)
+		case "name:":
+			q.Name = value
+		case "before:":
+			q.Before, err = config.ParseTime(value, now)
+		case "after:":
+			q.After, err = config.ParseTime(value, now)
+		case "size>":
+			var n int64
+			n, err = config.ParseSize(value)
+			q.MinSize = n + 1
+		case "size<":
+			if q.SizeBelow, err = config.ParseSize(value); err == nil && q.SizeBelow == 0 {
+				err = fmt.Errorf("nothing is smaller than %s", value)
+			}
+		}
+		if err != nil {
+			return q, fmt.Errorf("in %q: %w", field, err)
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+func cutQualifier(field string) (key, value string, ok bool) {
+	for _, key := range []string{"dir:", "name:", "before:", "after:", "size>", "size<"} {
+		if value, ok := strings.CutPrefix(field, key); ok {
+			return key, value, true
+		}
+	}
+	return "", "", false
+}
+
+// dirValue makes a dir: value that is a path absolute.
+func dirValue(value string) (string, error) {
+	switch {
+	case value == "~" || strings.HasPrefix(value, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, value[1:]), nil
+	case value == "." || value == ".." || strings.HasPrefix(value, "./") || strings.HasPrefix(value, "../"):
+		return filepath.Abs(value)
+	case filepath.IsAbs(value):
+		return filepath.Clean(value), nil
+	}
+	return value, nil
+}
+
+// Find returns the entries matching q, with its text and name: matched in
+// the given mode. Fuzzy results come best match first; the rest in the
+// order they were tossed. The text is matched in SQL, where substring
+// matching uses the trigram index, so only matching rows are read.
+func Find(d *sql.DB, q Query, mode string) ([]db.Entry, error) {
+	f := db.Filter{Since: q.After, MinSize: q.MinSize, SizeBelow: q.SizeBelow}
+	if !q.Before.IsZero() {
+		// Toss times are kept to the second, and Until is inclusive.
+		f.Until = q.Before.Add(-time.Second)
+	}
+	if filepath.IsAbs(q.Dir) {
+		f.PathPrefix = strings.TrimSuffix(q.Dir, "/") + "/"
+	}
+
+	var text, name matcher
+	var err error
+	switch mode {
+	case Substring:
+		f.Contains = q.Text
+	case Glob:
+		f.Glob = q.Text
+	case Regex:
+		if _, err := newMatcher(mode, q.Text); err != nil {
+			return nil, err
+		}
+		f.Regexp = q.Text
+	case Fuzzy:
+		// SQL finds the matches; scoring them is left to Go.
+		f.Fuzzy = q.Text
+		text, _ = newMatcher(mode, q.Text)
+	default:
+		return nil, fmt.Errorf("unknown search mode %q (want %s)", mode, strings.Join(Modes, ", "))
+	}
+	if name, err = newMatcher(mode, q.Name); err != nil {
+		return nil, err
+	}
+
+	entries, err := db.List(d, f)
+	if err != nil {
+		return nil, err
+	}
+	var out []db.Entry
+	scores := make(map[string]int)
+	for _, e := range entries {
+		if q.Dir != "" && !filepath.IsAbs(q.Dir) &&
+			!strings.Contains(strings.ToLower(filepath.Dir(e.OriginalPath)), strings.ToLower(q.Dir)) {
+			continue
+		}
+		score := 0
+		if text != nil {
+			s, ok := text(e.OriginalPath)
+			if !ok {
+				continue
+			}
+			score += s
+		}
+		if name != nil {
+			s, ok := name(filepath.Base(e.OriginalPath))
+			if !ok {
+				continue
+			}
+			score += s
+		}
+		scores[e.ID] = score
+		out = append(out, e)
+	}
+
+	if mode == Fuzzy {
+		slices.SortStableFunc(out, func(a, b db.Entry) int {
+			if scores[a.ID] != scores[b.ID] {
+				return scores[b.ID] - scores[a.ID]
+			}
+			return len(a.OriginalPath) - len(b.OriginalPath)
+		})
+	}
+	return out, nil
+}
+
+// matcher reports whether s matches and, for fuzzy matching, how well.
+type matcher func(s string) (int, bool)
+
+// newMatcher returns a matcher for pattern in mode, or nil for an empty
+// pattern, which matches everything.
+func newMatcher(mode, pattern string) (matcher, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	switch mode {
+	case Fuzzy:
+		return func(s string) (int, bool) {
+			score, _, ok := fuzzy.Score(pattern, s)
+			return score, ok
+		}, nil
+	case Glob:
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		return func(s string) (int, bool) {
+			ok, _ := filepath.Match(pattern, s)
+			return 0, ok
+		}, nil
+	case Regex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return func(s string) (int, bool) {
+			return 0, re.MatchString(s)
+		}, nil
+	}
+	lower := strings.ToLower(pattern)
+	return func(s string) (int, bool) {
+		return 0, strings.Contains(strings.ToLower(s), lower)
+	}, nil
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+var base = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// seed indexes five items tossed an hour apart, oldest first.
+func seed(t *testing.T) *sql.DB {
+	t.Helper()
+	d, err := db.Open(filepath.Join(t.TempDir(), "toss.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	entries := []db.Entry{
+		{ID: "a", OriginalPath: "/home/u/proj/main.go", SizeBytes: 300},
+		{ID: "b", OriginalPath: "/home/u/proj/build", IsDir: true, SizeBytes: 5000},
+		{ID: "c", OriginalPath: "/home/u/notes/meeting.txt", SizeBytes: 0},
+		{ID: "d", OriginalPath: "/tmp/main_test.go", SizeBytes: 200},
+		{ID: "e", OriginalPath: "/srv/mail/archive.tar", SizeBytes: 1 << 30},
+	}
+	for i, e := range entries {
+		e.BinName = e.ID + "-" + filepath.Base(e.OriginalPath)
+		e.TossedAt = base.Add(time.Duration(i) * time.Hour)
+		if err := db.Append(d, e); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	return d
+}
+
+func find(t *testing.T, d *sql.DB, query, mode string) string {
+	t.Helper()
+	q, err := Parse(query, base)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", query, err)
+	}
+	entries, err := Find(d, q, mode)
+	if err != nil {
+		t.Fatalf("Find(%q, %s): %v", query, mode, err)
+	}
+	var ids string
+	for _, e := range entries {
+		ids += e.ID
+	}
+	return ids
+}
+
+func TestParse(t *testing.T) {
+	q, err := Parse("main  dir:/home/u/ name:*.go before:2026-03-02 after:1d size>1KB size<2MB go", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Query{
+		Text:      "main go",
+		Dir:       "/home/u",
+		Name:      "*.go",
+		Before:    time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local),
+		After:     base.Add(-24 * time.Hour),
+		MinSize:   1025,
+		SizeBelow: 2 << 20,
+	}
+	if q != want {
+		t.Errorf("Parse:\n got %+v\nwant %+v", q, want)
+	}
+
+	for _, bad := range []string{"dir:", "before:someday", "size>lots", "size<0"} {
+		if _, err := Parse(bad, base); err == nil {
+			t.Errorf("Parse(%q): want an error", bad)
+		}
+	}
+}
+
+func TestParse_DirPaths(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	wd, _ := os.Getwd()
+	cases := map[string]string{
+		"dir:~/src": filepath.Join(home, "src"),
+		"dir:.":     wd,
+		"dir:/a/b/": "/a/b",
+		"dir:proj":  "proj",
+	}
+	for query, want := range cases {
+		if q, err := Parse(query, base); err != nil || q.Dir != want {
+			t.Errorf("Parse(%q).Dir = %q, %v; want %q", query, q.Dir, err, want)
+		}
+	}
+}
+
+func TestFind_Modes(t *testing.T) {
+	d := seed(t)
+	cases := []struct{ query, mode, want string }{
+		{"", Substring, "abcde"},
+		{"MAIN", Substring, "ad"},
+		{"mn", Fuzzy, "dac"}, // best first, the shorter of equal matches first
+		{"*.go", Glob, "ad"},
+		{"/home/*/proj/*", Glob, "ab"},
+		{`_test\.go$|\.tar$`, Regex, "de"},
+	}
+	for _, c := range cases {
+		if got := find(t, d, c.query, c.mode); got != c.want {
+			t.Errorf("%s %q: want %q, got %q", c.mode, c.query, c.want, got)
+		}
+	}
+}
+
+func TestFind_Qualifiers(t *testing.T) {
+	d := seed(t)
+	cases := []struct{ query, want string }{
+		{"dir:/home/u", "abc"},
+		{"dir:/home/u/proj/build", ""}, // the directory itself is not inside it
+		{"dir:proj", "ab"},
+		{"name:main", "ad"},
+		{"name:proj", ""},
+		{"before:2026-03-01T11:00:00Z", "ab"},
+		{"after:2026-03-01T11:00:00Z", "cde"},
+		{"size>300", "be"},
+		{"size<1", "c"},
+		{"size<1KB main", "ad"},
+	}
+	for _, c := range cases {
+		if got := find(t, d, c.query, Substring); got != c.want {
+			t.Errorf("%q: want %q, got %q", c.query, c.want, got)
+		}
+	}
+	if got := find(t, d, "name:*.go dir:/tmp", Glob); got != "d" {
+		t.Errorf("glob name: want d, got %q", got)
+	}
+	if got := find(t, d, "name:mntst", Fuzzy); got != "d" {
+		t.Errorf("fuzzy name: want d, got %q", got)
+	}
+}
+
+func TestFind_Errors(t *testing.T) {
+	d := seed(t)
+	if _, err := Find(d, Query{Text: "("}, Regex); err == nil {
+		t.Error("bad regex: want an error")
+	}
+	if _, err := Find(d, Query{Name: "["}, Glob); err == nil {
+		t.Error("bad glob: want an error")
+	}
+	if _, err := Find(d, Query{}, "telepathy"); err == nil {
+		t.Error("unknown mode: want an error")
+	}
+}
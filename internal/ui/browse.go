@@ -0,0 +1,405 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/roman91DE/toss/internal/db"
+	"github.com/roman91DE/toss/internal/fuzzy"
+)
+
+// Actions Browse can return.
+const (
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+const browseHelp = "type to filter  tab select  ctrl-a select all  enter restore  ctrl-x purge  ctrl-o info  esc quit"
+
+// Browse shows entries full screen, filtered by fuzzy matching query and
+// whatever is typed against their original paths, with a preview of the
+// item under the cursor; path gives where an item is in the bin. It
+// returns the action chosen and the items it applies to: those selected,
+// or else the one under the cursor. The action is "" if the user quit.
+// Stdin and stdout must be a terminal.
+func Browse(entries []db.Entry, query string, path func(db.Entry) string) (string, []db.Entry, error) {
+	t, err := openTerminal(os.Stdin, os.Stdout)
+	if err != nil {
+		return "", nil, err
+	}
+	defer t.close()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	m := newBrowser(entries, query, path)
+	out := bufio.NewWriter(t.out)
+	for redraw := true; ; {
+		select {
+		case <-winch:
+			redraw = true
+		default:
+		}
+		if redraw {
+			width, height := t.size()
+			m.render(out, width, height)
+			if err := out.Flush(); err != nil {
+				return "", nil, err
+			}
+		}
+		keys, err := t.readKeys(200)
+		if err != nil {
+			return "", nil, err
+		}
+		redraw = len(keys) > 0
+		for _, k := range keys {
+			if action, done := m.handle(k); done {
+				if action == "" {
+					return "", nil, nil
+				}
+				return action, m.chosen(), nil
+			}
+		}
+	}
+}
+
+// browser is the state of Browse, kept apart from the terminal so it can
+// be tested.
+type browser struct {
+	entries  []db.Entry
+	paths    []string
+	path     func(db.Entry) string
+	query    []rune
+	matches  []fuzzy.Match
+	cursor   int
+	top      int
+	rows     int // list rows at the last render, for paging
+	selected map[int]bool
+	info     bool
+	confirm  bool
+	previews map[int][]string
+}
+
+// previewLimit is the most lines of a file or entries of a directory
+// read for a preview.
+const previewLimit = 200
+
+func newBrowser(entries []db.Entry, query string, path func(db.Entry) string) *browser {
+	m := &browser{
+		entries:  entries,
+		path:     path,
+		query:    []rune(query),
+		rows:     1,
+		selected: make(map[int]bool),
+		previews: make(map[int][]string),
+	}
+	for _, e := range entries {
+		m.paths = append(m.paths, e.OriginalPath)
+	}
+	m.filter()
+	return m
+}
+
+func (m *browser) filter() {
+	m.matches = fuzzy.Rank(string(m.query), m.paths)
+	m.cursor, m.top = 0, 0
+}
+
+// handle applies one key and reports whether browsing is over, and with
+// which action.
+func (m *browser) handle(k string) (string, bool) {
+	if m.confirm {
+		m.confirm = false
+		if k == "y" || k == "Y" {
+			return ActionPurge, true
+		}
+		return "", false
+	}
+	switch k {
+	case keyEscape, "ctrl-c", "ctrl-g":
+		return "", true
+	case keyUp, "ctrl-p":
+		m.move(-1)
+	case keyDown, "ctrl-n":
+		m.move(1)
+	case keyPageUp:
+		m.move(-m.rows)
+	case keyPageDown:
+		m.move(m.rows)
+	case keyHome:
+		m.move(-len(m.matches))
+	case keyEnd:
+		m.move(len(m.matches))
+	case keyTab:
+		if len(m.matches) > 0 {
+			i := m.matches[m.cursor].Index
+			m.selected[i] = !m.selected[i]
+			m.move(1)
+		}
+	case "ctrl-a":
+		all := true
+		for _, match := range m.matches {
+			all = all && m.selected[match.Index]
+		}
+		for _, match := range m.matches {
+			m.selected[match.Index] = !all
+		}
+	case keyEnter:
+		if len(m.chosen()) > 0 {
+			return ActionRestore, true
+		}
+	case "ctrl-x":
+		m.confirm = len(m.chosen()) > 0
+	case "ctrl-o":
+		m.info = !m.info
+	case keyBackspace:
+		if len(m.query) > 0 {
+			m.query = m.query[:len(m.query)-1]
+			m.filter()
+		}
+	case "ctrl-u":
+		m.query = nil
+		m.filter()
+	default:
+		if r, size := utf8.DecodeRuneInString(k); size == len(k) && unicode.IsPrint(r) {
+			m.query = append(m.query, r)
+			m.filter()
+		}
+	}
+	return "", false
+}
+
+func (m *browser) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.matches)-1))
+}
+
+// chosen returns the selected items in bin order, or the one under the
+// cursor if none are.
+func (m *browser) chosen() []db.Entry {
+	var out []db.Entry
+	for i, e := range m.entries {
+		if m.selected[i] {
+			out = append(out, e)
+		}
+	}
+	if len(out) == 0 && len(m.matches) > 0 {
+		out = append(out, m.entries[m.matches[m.cursor].Index])
+	}
+	return out
+}
+
+// render draws the whole screen: the query, the list with a preview pane
+// beside it on wide terminals, and a help or confirmation line.
+func (m *browser) render(w io.Writer, width, height int) {
+	m.rows = max(height-2, 1)
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+m.rows {
+		m.top = m.cursor - m.rows + 1
+	}
+	listWidth, previewWidth := width, 0
+	if width >= 100 {
+		previewWidth = width * 2 / 5
+		listWidth = width - previewWidth - 3
+	}
+
+	fmt.Fprint(w, "\x1b[H")
+	count := fmt.Sprintf("%d/%d", len(m.matches), len(m.entries))
+	if n := m.numSelected(); n > 0 {
+		count += fmt.Sprintf(" (%d selected)", n)
+	}
+	prompt := "> " + sanitize(string(m.query))
+	fmt.Fprintf(w, "%s%*s\x1b[K\r\n", prompt, max(width-utf8.RuneCountInString(prompt), 0), truncate(count, width/2))
+
+	preview := m.preview()
+	for r := 0; r < m.rows; r++ {
+		if i := m.top + r; i < len(m.matches) {
+			m.writeRow(w, i, listWidth)
+		} else {
+			fmt.Fprint(w, strings.Repeat(" ", listWidth))
+		}
+		if previewWidth > 0 {
+			line := ""
+			if r < len(preview) {
+				line = preview[r]
+			}
+			fmt.Fprintf(w, " \x1b[2m│\x1b[22m %s", truncate(sanitize(line), previewWidth))
+		}
+		fmt.Fprint(w, "\x1b[K\r\n")
+	}
+
+	status := browseHelp
+	if m.confirm {
+		status = fmt.Sprintf("Permanently delete %d item(s)? [y/N]", len(m.chosen()))
+	}
+	fmt.Fprintf(w, "\x1b[7m%s\x1b[27m\x1b[K", truncate(status, width))
+	// Leave the cursor where the next character of the query goes.
+	fmt.Fprintf(w, "\x1b[1;%dH\x1b[?25h", min(utf8.RuneCountInString(prompt)+1, width))
+}
+
+func (m *browser) numSelected() int {
+	n := 0
+	for _, ok := range m.selected {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// writeRow draws match i, width columns wide: a selection mark, the toss
+// time, the size and the path, with the matched characters highlighted
+// and the start of the path cut if it does not fit.
+func (m *browser) writeRow(w io.Writer, i, width int) {
+	match := m.matches[i]
+	e := m.entries[match.Index]
+	mark := "  "
+	if m.selected[match.Index] {
+		mark = "* "
+	}
+	prefix := fmt.Sprintf("%s%s  %7s  ", mark, e.TossedAt.Format("2006-01-02 15:04"), FormatSize(e.SizeBytes))
+	prefix = truncate(prefix, width)
+
+	path := []rune(sanitize(m.paths[match.Index]))
+	avail := width - utf8.RuneCountInString(prefix)
+	cut := 0
+	if len(path) > avail && avail > 0 {
+		cut = len(path) - avail + 1
+	}
+	highlight := make(map[int]bool, len(match.Positions))
+	for _, p := range match.Positions {
+		highlight[p] = true
+	}
+
+	var b strings.Builder
+	if i == m.cursor {
+		b.WriteString("\x1b[7m")
+	}
+	b.WriteString(prefix)
+	used := utf8.RuneCountInString(prefix)
+	if avail > 0 {
+		if cut > 0 {
+			b.WriteRune('…')
+			used++
+		}
+		for j := cut; j < len(path); j++ {
+			if highlight[j] {
+				b.WriteString("\x1b[1;33m")
+				b.WriteRune(path[j])
+				b.WriteString("\x1b[22;39m")
+			} else {
+				b.WriteRune(path[j])
+			}
+			used++
+		}
+	}
+	b.WriteString(strings.Repeat(" ", max(width-used, 0)))
+	if i == m.cursor {
+		b.WriteString("\x1b[27m")
+	}
+	io.WriteString(w, b.String())
+}
+
+// preview returns the lines shown beside the list for the item under the
+// cursor: its contents, or with ctrl-o everything recorded about it.
+func (m *browser) preview() []string {
+	if len(m.matches) == 0 {
+		return nil
+	}
+	i := m.matches[m.cursor].Index
+	e := m.entries[i]
+	if m.info {
+		var buf bytes.Buffer
+		WriteInfo(&buf, e, m.path(e))
+		return strings.Split(buf.String(), "\n")
+	}
+	if lines, ok := m.previews[i]; ok {
+		return lines
+	}
+	lines := previewLines(m.path(e), previewLimit)
+	m.previews[i] = lines
+	return lines
+}
+
+// previewLines shows the start of a text file, the tree of a directory, or
+// a note about anything else.
+func previewLines(path string, limit int) []string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return []string{"cannot preview: " + err.Error()}
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, _ := os.Readlink(path)
+		return []string{"symlink to " + target}
+	case info.IsDir():
+		var lines []string
+		filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || p == path {
+				return nil
+			}
+			if len(lines) == limit {
+				lines = append(lines, "…")
+				return filepath.SkipAll
+			}
+			rel, _ := filepath.Rel(path, p)
+			name := strings.Repeat("  ", strings.Count(rel, string(filepath.Separator))) + d.Name()
+			if d.IsDir() {
+				name += "/"
+			}
+			lines = append(lines, name)
+			return nil
+		})
+		if len(lines) == 0 {
+			return []string{"(empty directory)"}
+		}
+		return lines
+	case !info.Mode().IsRegular():
+		return []string{"special file: " + info.Mode().String()}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return []string{"cannot preview: " + err.Error()}
+	}
+	defer f.Close()
+	head := make([]byte, 64*1024)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return []string{fmt.Sprintf("binary file, %s", FormatSize(info.Size()))}
+	}
+	lines := strings.Split(strings.ReplaceAll(string(head), "\t", "    "), "\n")
+	return lines[:min(len(lines), limit)]
+}
+
+// sanitize replaces control characters, C1 ones such as CSI included,
+// which would garble the screen.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// truncate cuts s to at most width runes.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:max(width, 0)])
+}
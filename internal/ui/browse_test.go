@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func browseEntries(t *testing.T) ([]db.Entry, func(db.Entry) string) {
+	t.Helper()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "1"), []byte("hello\nworld\n"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "2", "sub"), 0o755)
+	os.WriteFile(filepath.Join(dir, "2", "sub", "a.txt"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "3"), []byte{0, 1, 2}, 0o644)
+	at := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	entries := []db.Entry{
+		{ID: "1", OriginalPath: "/home/u/notes.txt", TossedAt: at},
+		{ID: "2", OriginalPath: "/home/u/project", TossedAt: at, IsDir: true},
+		{ID: "3", OriginalPath: "/home/u/photo.jpg", TossedAt: at},
+	}
+	return entries, func(e db.Entry) string { return filepath.Join(dir, e.ID) }
+}
+
+func ids(entries []db.Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func typeKeys(m *browser, keys ...string) (string, bool) {
+	for _, k := range keys {
+		if action, done := m.handle(k); done {
+			return action, done
+		}
+	}
+	return "", false
+}
+
+func TestBrowser_Filter(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "", path)
+	if len(m.matches) != 3 {
+		t.Fatalf("empty query: got %d matches, want 3", len(m.matches))
+	}
+	typeKeys(m, "p", "h", "o")
+	if got := ids(m.chosen()); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("after typing pho: chosen %v, want [3]", got)
+	}
+	typeKeys(m, keyBackspace, keyBackspace)
+	if len(m.matches) != 2 {
+		t.Errorf("after backspace: got %d matches, want 2", len(m.matches))
+	}
+}
+
+func TestBrowser_InitialQuery(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "proj", path)
+	if got := ids(m.chosen()); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("chosen %v, want [2]", got)
+	}
+}
+
+func TestBrowser_SelectAndRestore(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "", path)
+	// Select the first and third items, leaving the cursor on the second.
+	typeKeys(m, keyTab, keyDown, keyTab, keyUp)
+	if got := ids(m.chosen()); !slices.Equal(got, []string{"1", "3"}) {
+		t.Errorf("chosen %v, want [1 3]", got)
+	}
+	action, done := typeKeys(m, keyEnter)
+	if !done || action != ActionRestore {
+		t.Errorf("enter: got %q, %v, want %q, true", action, done, ActionRestore)
+	}
+}
+
+func TestBrowser_SelectAll(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "", path)
+	typeKeys(m, "ctrl-a")
+	if got := len(m.chosen()); got != 3 {
+		t.Errorf("after ctrl-a: %d chosen, want 3", got)
+	}
+	typeKeys(m, "ctrl-a")
+	if got := ids(m.chosen()); !slices.Equal(got, []string{"1"}) {
+		t.Errorf("after second ctrl-a: chosen %v, want the cursor item [1]", got)
+	}
+}
+
+func TestBrowser_PurgeConfirm(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "", path)
+	if _, done := typeKeys(m, "ctrl-x", "n"); done {
+		t.Fatal("ctrl-x then n ended browsing")
+	}
+	if m.confirm {
+		t.Error("confirmation still pending after n")
+	}
+	action, done := typeKeys(m, "ctrl-x", "y")
+	if !done || action != ActionPurge {
+		t.Errorf("ctrl-x then y: got %q, %v, want %q, true", action, done, ActionPurge)
+	}
+}
+
+func TestBrowser_Quit(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "", path)
+	if action, done := typeKeys(m, keyEscape); !done || action != "" {
+		t.Errorf("esc: got %q, %v, want \"\", true", action, done)
+	}
+}
+
+func TestBrowser_Render(t *testing.T) {
+	entries, path := browseEntries(t)
+	m := newBrowser(entries, "", path)
+	var b strings.Builder
+	m.render(&b, 120, 10)
+	out := b.String()
+	for _, want := range []string{"3/3", "/home/u/notes.txt", "2026-01-02 03:04", "hello", "world", browseHelp[:20]} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render output missing %q", want)
+		}
+	}
+	if n := strings.Count(out, "\r\n"); n != 9 {
+		t.Errorf("render wrote %d line breaks, want 9 for a height of 10", n)
+	}
+
+	// Narrow terminals have no preview pane.
+	b.Reset()
+	m.render(&b, 60, 10)
+	if strings.Contains(b.String(), "hello") {
+		t.Error("preview shown on a 60 column terminal")
+	}
+}
+
+func TestPreviewLines(t *testing.T) {
+	entries, path := browseEntries(t)
+	if got := previewLines(path(entries[0]), 1); !slices.Equal(got, []string{"hello"}) {
+		t.Errorf("file preview = %q, want [hello]", got)
+	}
+	if got := previewLines(path(entries[1]), 10); !slices.Equal(got, []string{"sub/", "  a.txt"}) {
+		t.Errorf("directory preview = %q, want [sub/   a.txt]", got)
+	}
+	if got := previewLines(path(entries[2]), 10); !slices.Equal(got, []string{"binary file, 3B"}) {
+		t.Errorf("binary preview = %q", got)
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	got := splitKeys([]byte("a\x1b[A\x1b[6~\t\r\x7f\x01é\x1b"))
+	want := []string{"a", keyUp, keyPageDown, keyTab, keyEnter, keyBackspace, "ctrl-a", "é", keyEscape}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitKeys = %q, want %q", got, want)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a\x1b[2Jb\n", "a?[2Jb?"},
+		{"a\u009b2Jb", "a?2Jb"},
+		{"a\x7fé", "a?é"},
+	}
+	for _, tt := range tests {
+		if got := sanitize(tt.in); got != tt.want {
+			t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
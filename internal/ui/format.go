@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+// Formats lists the machine-readable formats accepted by WriteEntries.
+var Formats = []string{"json", "ndjson", "csv", "tsv"}
+
+var fieldNames = []string{
+	"id", "original_path", "bin_name", "bin_dir", "tossed_at", "is_dir", "size_bytes",
+	"mode", "uid", "gid", "mtime", "link_target", "hostname", "cwd", "command", "user", "batch",
+}
+
+func WriteEntries(w io.Writer, entries []db.Entry, format string) error {
+	switch format {
+	case "json":
+		if entries == nil {
+			entries = []db.Entry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv", "tsv":
+		cw := csv.NewWriter(w)
+		if format == "tsv" {
+			cw.Comma = '\t'
+		}
+		if err := cw.Write(fieldNames); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := cw.Write(record(e)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+func record(e db.Entry) []string {
+	return []string{
+		e.ID,
+		e.OriginalPath,
+		e.BinName,
+		e.BinDir,
+		e.TossedAt.Format(time.RFC3339),
+		strconv.FormatBool(e.IsDir),
+		strconv.FormatInt(e.SizeBytes, 10),
+		e.Mode.String(),
+		strconv.Itoa(e.UID),
+		strconv.Itoa(e.GID),
+		formatTime(e.ModTime, time.RFC3339Nano),
+		e.LinkTarget,
+		e.Hostname,
+		e.Cwd,
+		e.Command,
+		e.User,
+		e.Batch,
+	}
+}
+
+// formatTime leaves unknown (zero) times empty.
+func formatTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+var templateFuncs = template.FuncMap{
+	"size":    FormatSize,
+	"rfc3339": func(t time.Time) string { return formatTime(t, time.RFC3339) },
+}
+
+// WriteTemplate executes a text/template once per entry, each followed by a
+// newline. Besides the db.Entry fields, templates can use the size and
+// rfc3339 functions.
+func WriteTemplate(w io.Writer, entries []db.Entry, text string) error {
+	tmpl, err := template.New("entry").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+	for _, e := range entries {
+		if err := tmpl.Execute(w, e); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman91DE/toss/internal/db"
+)
+
+func formatEntries() []db.Entry {
+	tossed := time.Date(2026, 2, 18, 10, 0, 0, 0, time.UTC)
+	return []db.Entry{
+		{ID: "id-1", OriginalPath: "/home/user/notes.txt", BinName: "id-1-notes.txt", BinDir: "/home/user/.toss/files", TossedAt: tossed, SizeBytes: 42},
+		{ID: "id-2", OriginalPath: "/home/user/a, b", BinName: "id-2-a, b", TossedAt: tossed.Add(time.Hour), IsDir: true, SizeBytes: 2048},
+	}
+}
+
+func TestWriteEntries_JSON(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteEntries(&buf, formatEntries(), "json"); err != nil {
+		t.Fatalf("WriteEntries: %v", err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("want 2 objects, got %d", len(got))
+	}
+	if got[0]["original_path"] != "/home/user/notes.txt" {
+		t.Errorf("original_path: got %v", got[0]["original_path"])
+	}
+	if got[0]["tossed_at"] != "2026-02-18T10:00:00Z" {
+		t.Errorf("tossed_at: got %v", got[0]["tossed_at"])
+	}
+	if got[1]["is_dir"] != true {
+		t.Errorf("is_dir: got %v", got[1]["is_dir"])
+	}
+	if got[1]["size_bytes"] != float64(2048) {
+		t.Errorf("size_bytes: got %v", got[1]["size_bytes"])
+	}
+}
+
+func TestWriteEntries_JSONEmpty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteEntries(&buf, nil, "json"); err != nil {
+		t.Fatalf("WriteEntries: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("empty bin should encode as [], got %q", got)
+	}
+}
+
+func TestWriteEntries_NDJSON(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteEntries(&buf, formatEntries(), "ndjson"); err != nil {
+		t.Fatalf("WriteEntries: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var e db.Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Errorf("line is not a JSON object: %v: %s", err, line)
+		}
+	}
+}
+
+func TestWriteEntries_CSV(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteEntries(&buf, formatEntries(), "csv"); err != nil {
+		t.Fatalf("WriteEntries: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("want header + 2 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != "id,original_path,bin_name,bin_dir,tossed_at,is_dir,size_bytes,"+
+		"mode,uid,gid,mtime,link_target,hostname,cwd,command,user,batch" {
+		t.Errorf("header: got %v", records[0])
+	}
+	if records[2][1] != "/home/user/a, b" {
+		t.Errorf("path with comma not round-tripped: got %q", records[2][1])
+	}
+	if records[2][5] != "true" || records[2][6] != "2048" {
+		t.Errorf("is_dir/size_bytes: got %q/%q", records[2][5], records[2][6])
+	}
+}
+
+func TestWriteEntries_TSV(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteEntries(&buf, formatEntries(), "tsv"); err != nil {
+		t.Fatalf("WriteEntries: %v", err)
+	}
+	first := strings.SplitN(buf.String(), "\n", 2)[0]
+	if got := len(strings.Split(first, "\t")); got != 17 {
+		t.Errorf("want 17 tab-separated columns, got %d: %q", got, first)
+	}
+}
+
+func TestWriteEntries_UnknownFormat(t *testing.T) {
+	t.Parallel()
+	if err := WriteEntries(&bytes.Buffer{}, nil, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := WriteTemplate(&buf, formatEntries(), "{{.ID}} {{size .SizeBytes}} {{rfc3339 .TossedAt}}")
+	if err != nil {
+		t.Fatalf("WriteTemplate: %v", err)
+	}
+	want := "id-1 42B 2026-02-18T10:00:00Z\nid-2 2.0KB 2026-02-18T11:00:00Z\n"
+	if buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+}
+
+func TestWriteTemplate_ParseError(t *testing.T) {
+	t.Parallel()
+	if err := WriteTemplate(&bytes.Buffer{}, nil, "{{.ID"); err == nil {
+		t.Error("expected error for malformed template")
+	}
+}
@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/sys/unix"
+)
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetTermios(int(f.Fd()), ioctlGetTermios)
+	return err == nil
+}
+
+// terminal is the controlling terminal in raw mode on the alternate
+// screen, for full-screen UIs.
+type terminal struct {
+	in, out *os.File
+	saved   *unix.Termios
+}
+
+func openTerminal(in, out *os.File) (*terminal, error) {
+	fd := int(in.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("reading terminal settings: %w", err)
+	}
+	raw := *saved
+	raw.Iflag &^= unix.ICRNL | unix.IXON | unix.BRKINT | unix.INPCK | unix.ISTRIP
+	raw.Lflag &^= unix.ECHO | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, fmt.Errorf("setting terminal to raw mode: %w", err)
+	}
+	// Alternate screen, cursor hidden.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	return &terminal{in: in, out: out, saved: saved}, nil
+}
+
+func (t *terminal) close() {
+	fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+	unix.IoctlSetTermios(int(t.in.Fd()), ioctlSetTermios, t.saved)
+}
+
+func (t *terminal) size() (width, height int) {
+	ws, err := unix.IoctlGetWinsize(int(t.out.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// readKeys waits up to timeoutMs for input and returns the keys read, or
+// none on timeout. A hung-up terminal is an error.
+func (t *terminal) readKeys(timeoutMs int) ([]string, error) {
+	fds := []unix.PollFd{{Fd: int32(t.in.Fd()), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, timeoutMs)
+	if err == unix.EINTR || n == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fds[0].Revents&(unix.POLLHUP|unix.POLLERR) != 0 {
+		return nil, fmt.Errorf("terminal closed")
+	}
+	buf := make([]byte, 256)
+	n, err = unix.Read(int(t.in.Fd()), buf)
+	if err != nil {
+		return nil, err
+	}
+	return splitKeys(buf[:n]), nil
+}
+
+// Names of the keys splitKeys reports by name rather than as the text
+// they type.
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyTab       = "tab"
+	keyBackspace = "backspace"
+	keyEscape    = "esc"
+)
+
+// splitKeys splits raw terminal input into keys: escape sequences and
+// control characters by name (keyUp, "ctrl-a", ...) and anything else as
+// the character typed.
+func splitKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) > 2 && (b[1] == '[' || b[1] == 'O'):
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			if end == len(b) {
+				return append(keys, keyEscape)
+			}
+			keys = append(keys, escapeKey(string(b[2:end+1])))
+			b = b[end+1:]
+		case b[0] == 0x1b:
+			keys = append(keys, keyEscape)
+			b = b[1:]
+		case b[0] == '\r' || b[0] == '\n':
+			keys = append(keys, keyEnter)
+			b = b[1:]
+		case b[0] == '\t':
+			keys = append(keys, keyTab)
+			b = b[1:]
+		case b[0] == 0x7f || b[0] == 0x08:
+			keys = append(keys, keyBackspace)
+			b = b[1:]
+		case b[0] < 0x20:
+			keys = append(keys, "ctrl-"+string(rune('a'+b[0]-1)))
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+		}
+	}
+	return keys
+}
+
+func escapeKey(seq string) string {
+	switch seq {
+	case "A":
+		return keyUp
+	case "B":
+		return keyDown
+	case "5~":
+		return keyPageUp
+	case "6~":
+		return keyPageDown
+	case "H", "1~", "7~":
+		return keyHome
+	case "F", "4~", "8~":
+		return keyEnd
+	}
+	return "esc[" + seq
+}
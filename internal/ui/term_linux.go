@@ -0,0 +1,9 @@
+package ui
+
+import "golang.org/x/sys/unix"
+
+// ioctls that read and set the terminal settings.
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
@@ -3,7 +3,10 @@ package ui
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"os/user"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -11,10 +14,25 @@ import (
 	"github.com/roman91DE/toss/internal/db"
 )
 
+// stdin reads the answers to every prompt of a run. A reader per prompt
+// would buffer the answers piped in for the later ones and lose them.
+var stdin struct {
+	file   *os.File
+	reader *bufio.Reader
+}
+
+// stdinReader returns the reader of os.Stdin, starting over if os.Stdin
+// was replaced.
+func stdinReader() *bufio.Reader {
+	if stdin.reader == nil || stdin.file != os.Stdin {
+		stdin.file, stdin.reader = os.Stdin, bufio.NewReader(os.Stdin)
+	}
+	return stdin.reader
+}
+
 func Confirm(prompt string) (bool, error) {
 	fmt.Printf("%s [y/N] ", prompt)
-	reader := bufio.NewReader(os.Stdin)
-	line, err := reader.ReadString('\n')
+	line, err := stdinReader().ReadString('\n')
 	if err != nil {
 		return false, err
 	}
@@ -22,15 +40,37 @@ func Confirm(prompt string) (bool, error) {
 	return answer == "y" || answer == "yes", nil
 }
 
+// ConfirmOrDiff is Confirm with a third answer, d, that calls showDiff and
+// asks again. A diff that fails is reported, and the question stands.
+func ConfirmOrDiff(prompt string, showDiff func() error) (bool, error) {
+	reader := stdinReader()
+	for {
+		fmt.Printf("%s [y/N/d] ", prompt)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return false, err
+		}
+		switch strings.TrimSpace(strings.ToLower(line)) {
+		case "y", "yes":
+			return true, nil
+		case "d", "diff":
+			if err := showDiff(); err != nil {
+				fmt.Fprintf(os.Stderr, "toss: diff: %v\n", err)
+			}
+		default:
+			return false, nil
+		}
+	}
+}
+
 func PickEntry(entries []db.Entry) (db.Entry, error) {
 	fmt.Println("Multiple matches found:")
 	for i, e := range entries {
-		fmt.Printf("  %d) %s  (%s)\n", i+1, e.OriginalPath, e.TossedAt.Format("2006-01-02 15:04"))
+		fmt.Printf("  %d) %s  (%s, %s)\n", i+1, e.OriginalPath, e.TossedAt.Format("2006-01-02 15:04"), db.ShortID(e.ID))
 	}
 	fmt.Printf("Choose [1-%d]: ", len(entries))
 
-	reader := bufio.NewReader(os.Stdin)
-	line, err := reader.ReadString('\n')
+	line, err := stdinReader().ReadString('\n')
 	if err != nil {
 		return db.Entry{}, err
 	}
@@ -43,14 +83,15 @@ func PickEntry(entries []db.Entry) (db.Entry, error) {
 
 func PrintTable(entries []db.Entry) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NO.\tTOSSED AT\tSIZE\tPATH")
+	fmt.Fprintln(w, "NO.\tID\tTOSSED AT\tSIZE\tPATH")
 	for i, e := range entries {
 		name := e.OriginalPath
 		if e.IsDir {
 			name += " [dir]"
 		}
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
 			i+1,
+			db.ShortID(e.ID),
 			e.TossedAt.Format("2006-01-02 15:04"),
 			FormatSize(e.SizeBytes),
 			name,
@@ -59,6 +100,107 @@ func PrintTable(entries []db.Entry) {
 	w.Flush()
 }
 
+// PrintHistory lists the versions of one path, oldest first, numbered
+// from 1.
+func PrintHistory(versions []db.Entry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tID\tTOSSED AT\tMODIFIED\tSIZE")
+	for i, e := range versions {
+		modified := "-"
+		if !e.ModTime.IsZero() {
+			modified = e.ModTime.Format("2006-01-02 15:04")
+		}
+		size := FormatSize(e.SizeBytes)
+		if e.IsDir {
+			size += " [dir]"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			i+1,
+			db.ShortID(e.ID),
+			e.TossedAt.Format("2006-01-02 15:04"),
+			modified,
+			size,
+		)
+	}
+	w.Flush()
+}
+
+// PrintInfo shows everything recorded about one item; path is where it
+// currently is in the bin. Details an older toss did not record are shown
+// as unknown.
+func PrintInfo(e db.Entry, path string) {
+	WriteInfo(os.Stdout, e, path)
+}
+
+// WriteInfo is PrintInfo writing to out.
+func WriteInfo(out io.Writer, e db.Entry, path string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	row := func(label, value string) {
+		if value == "" {
+			value = "unknown"
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", label, value)
+	}
+	known := !e.ModTime.IsZero()
+
+	row("ID", e.ID)
+	row("Original path", e.OriginalPath)
+	row("In bin", path)
+	row("Tossed at", e.TossedAt.Format("2006-01-02 15:04:05"))
+	row("Type", kind(e, known))
+	row("Size", FormatSize(e.SizeBytes))
+	if known {
+		row("Mode", fmt.Sprintf("%04o (%s)", uint32(e.Mode.Perm()), e.Mode.Perm()))
+		row("Owner", owner(e.UID, false))
+		row("Group", owner(e.GID, true))
+		row("Modified", e.ModTime.Format("2006-01-02 15:04:05"))
+	} else {
+		for _, label := range []string{"Mode", "Owner", "Group", "Modified"} {
+			row(label, "")
+		}
+	}
+	if e.LinkTarget != "" {
+		row("Link target", e.LinkTarget)
+	}
+	row("User", e.User)
+	row("Host", e.Hostname)
+	row("Working dir", e.Cwd)
+	row("Command", e.Command)
+	w.Flush()
+}
+
+func kind(e db.Entry, known bool) string {
+	switch {
+	case e.IsDir:
+		return "directory"
+	case !known:
+		return "file"
+	case e.Mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case e.Mode.IsRegular():
+		return "file"
+	default:
+		return "special file (" + e.Mode.Type().String() + ")"
+	}
+}
+
+// owner shows a uid or gid with its name, if this system knows it.
+func owner(id int, group bool) string {
+	s := strconv.Itoa(id)
+	name := ""
+	if group {
+		if g, err := user.LookupGroupId(s); err == nil {
+			name = g.Name
+		}
+	} else if u, err := user.LookupId(s); err == nil {
+		name = u.Username
+	}
+	if name == "" {
+		return s
+	}
+	return fmt.Sprintf("%s (%s)", name, s)
+}
+
 func FormatSize(bytes int64) string {
 	const (
 		KB = 1024
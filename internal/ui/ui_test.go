@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"strings"
 	"testing"
@@ -183,6 +185,72 @@ func TestConfirm_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestConfirm_PipedAnswersForTwoPrompts(t *testing.T) {
+	replaceStdin(t, "y\nn\n")
+	var first, second bool
+	var err1, err2 error
+	captureStdout(t, func() {
+		first, err1 = Confirm("Overwrite a?")
+		second, err2 = ConfirmOrDiff("Overwrite b?", func() error { return nil })
+	})
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Confirm: %v, ConfirmOrDiff: %v", err1, err2)
+	}
+	if !first || second {
+		t.Errorf("want yes then no, got %v then %v", first, second)
+	}
+}
+
+func TestConfirmOrDiff_ShowsDiffThenAsksAgain(t *testing.T) {
+	replaceStdin(t, "d\ny\n")
+	shown := 0
+	var got bool
+	var err error
+	captureStdout(t, func() {
+		got, err = ConfirmOrDiff("Overwrite?", func() error {
+			shown++
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("ConfirmOrDiff: %v", err)
+	}
+	if shown != 1 || !got {
+		t.Errorf("want the diff shown once and then true, got %d and %v", shown, got)
+	}
+}
+
+func TestConfirmOrDiff_FailedDiffAsksAgain(t *testing.T) {
+	replaceStdin(t, "d\ny\n")
+	var got bool
+	var err error
+	captureStdout(t, func() {
+		got, err = ConfirmOrDiff("Overwrite?", func() error {
+			return errors.New("binary files differ")
+		})
+	})
+	if err != nil {
+		t.Fatalf("ConfirmOrDiff: %v", err)
+	}
+	if !got {
+		t.Error("want the question asked again after the failed diff, and true")
+	}
+}
+
+func TestConfirmOrDiff_Default(t *testing.T) {
+	replaceStdin(t, "\n")
+	var got bool
+	captureStdout(t, func() {
+		got, _ = ConfirmOrDiff("Overwrite?", func() error {
+			t.Error("diff should not be shown")
+			return nil
+		})
+	})
+	if got {
+		t.Error("expected false for empty input (default N)")
+	}
+}
+
 // PickEntry tests (stdin replacement — no t.Parallel)
 
 func makeTestEntries(n int) []db.Entry {
@@ -280,3 +348,84 @@ func TestPrintTable_DirSuffix(t *testing.T) {
 		t.Errorf("expected '[dir]' in output for directory entry; got:\n%s", output)
 	}
 }
+
+func TestPrintTable_ShortID(t *testing.T) {
+	entries := []db.Entry{
+		{ID: "3f2a1b4c-0000-4000-8000-000000000001", OriginalPath: "/a.txt", TossedAt: time.Now()},
+	}
+	output := captureStdout(t, func() {
+		PrintTable(entries)
+	})
+	if !strings.Contains(output, "3f2a1b4c ") || strings.Contains(output, "3f2a1b4c-0000") {
+		t.Errorf("expected 8-character short ID in output; got:\n%s", output)
+	}
+}
+
+// PrintInfo tests (stdout capture)
+
+func TestPrintInfo_ShowsMetadata(t *testing.T) {
+	e := db.Entry{
+		ID:           "3f2a1b4c-0000-4000-8000-000000000001",
+		OriginalPath: "/home/u/link",
+		TossedAt:     time.Now(),
+		Mode:         fs.ModeSymlink | 0777,
+		ModTime:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local),
+		LinkTarget:   "target.txt",
+		Hostname:     "laptop",
+		Cwd:          "/home/u",
+		Command:      "toss link",
+		User:         "u",
+	}
+	output := captureStdout(t, func() {
+		PrintInfo(e, "/home/u/.toss/files/x-link")
+	})
+	for _, want := range []string{"symlink", "0777 (-rwxrwxrwx)", "target.txt", "laptop", "toss link", "2026-01-02 03:04:05"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q; got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "unknown") {
+		t.Errorf("nothing should be unknown; got:\n%s", output)
+	}
+}
+
+func TestPrintInfo_LegacyEntryIsUnknown(t *testing.T) {
+	e := db.Entry{ID: "id-1", OriginalPath: "/a.txt", TossedAt: time.Now()}
+	output := captureStdout(t, func() {
+		PrintInfo(e, "/bin/id-1-a.txt")
+	})
+	for _, label := range []string{"Mode", "Owner", "Modified", "User", "Command"} {
+		if !strings.Contains(output, label+":") {
+			t.Errorf("output missing %s; got:\n%s", label, output)
+		}
+	}
+	if n := strings.Count(output, "unknown"); n != 8 {
+		t.Errorf("want 8 unknown fields, got %d; output:\n%s", n, output)
+	}
+	if strings.Contains(output, "Owner:  0") || strings.Contains(output, "root") {
+		t.Errorf("a legacy entry must not be shown as owned by root; got:\n%s", output)
+	}
+}
+
+// PrintHistory tests (stdout capture)
+
+func TestPrintHistory_NumbersVersions(t *testing.T) {
+	tossed := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+	versions := []db.Entry{
+		{ID: "aaaaaaaa-1", TossedAt: tossed, SizeBytes: 10},
+		{ID: "bbbbbbbb-2", TossedAt: tossed.Add(time.Hour), ModTime: tossed, SizeBytes: 20},
+	}
+	output := captureStdout(t, func() {
+		PrintHistory(versions)
+	})
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want header + 2 rows, got:\n%s", output)
+	}
+	if !strings.HasPrefix(lines[1], "1 ") || !strings.Contains(lines[1], "aaaaaaaa") || !strings.Contains(lines[1], " - ") {
+		t.Errorf("first row should be version 1 with unknown mtime; got %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "2 ") || !strings.Contains(lines[2], "2026-03-01 09:00") {
+		t.Errorf("second row should be version 2 with its mtime; got %q", lines[2])
+	}
+}